@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Valorith/EQRaidAssist/config"
+	"github.com/Valorith/EQRaidAssist/logger"
 	"github.com/Valorith/EQRaidAssist/mongodb"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -73,10 +75,10 @@ func (aliases *Aliases) UpdateDB() error {
 			return fmt.Errorf("aliases: UpdateDB(): mongodb.AliasDB.Connect(): %w", err)
 		}
 	}
-	fmt.Println("Dropping Alias Collection...")
+	logger.Infof(logger.Alias, "Dropping Alias Collection...")
 	mongodb.AliasDB.Collection.Drop(mongodb.AliasDB.Context)
 	for _, alias := range aliases.List {
-		fmt.Printf("Updating DB with alias: %s\n", alias.Handle)
+		logger.Debugf(logger.Alias, "Updating DB with alias: %s", alias.Handle)
 		err := mongodb.AliasDB.Insert(alias)
 		if err != nil {
 			return fmt.Errorf("aliases.UpdateDB(): mongodb.AliasDB.Insert(): %w", err)
@@ -125,7 +127,7 @@ func (aliases *Aliases) LoadFromDB() error {
 // Adds a character to the specified alias
 func (a *Alias) AddCharacter(character string) {
 	a.Characters = append(a.Characters, character)
-	fmt.Printf("%s added as an alias of handle: %s\n", character, a.Handle)
+	logger.Infof(logger.Alias, "%s added as an alias of handle: %s", character, a.Handle)
 }
 
 // Removes a character from the specified alias
@@ -151,21 +153,21 @@ func (a *Alias) HasCharacter(character string) bool {
 // Adds an alias to the ActiveAliases list
 func AddAlias(characterName, handle string) error {
 	if IsNameHandle(handle) {
-		fmt.Printf("%s is already a handle. Going to add %s to it.\n", handle, characterName)
+		logger.Debugf(logger.Alias, "%s is already a handle. Going to add %s to it.", handle, characterName)
 		selectedAlias, err := GetHandleAlias(handle)
-		fmt.Printf("The selected handle is: %s\n", selectedAlias.Handle)
+		logger.Debugf(logger.Alias, "The selected handle is: %s", selectedAlias.Handle)
 		if err != nil {
 			return fmt.Errorf("AddAlias(): GetHandleAlias(): %w", err)
 		}
 		selectedAlias.AddCharacter(characterName)
 	} else {
-		fmt.Printf("%s is not currently a handle. Creating new alias for %s, under that new handle.\n", handle, characterName)
+		logger.Infof(logger.Alias, "%s is not currently a handle. Creating new alias for %s, under that new handle.", handle, characterName)
 		// Create a new alias and add it to the list
 		newAlias := Alias{
 			Handle:     handle,
 			Characters: []string{characterName}}
 		ActiveAliases.List = append(ActiveAliases.List, newAlias)
-		fmt.Printf("%s added as an alias of handle: %s\n", characterName, handle)
+		logger.Infof(logger.Alias, "%s added as an alias of handle: %s", characterName, handle)
 	}
 
 	err := ActiveAliases.UpdateDB()
@@ -247,7 +249,7 @@ func TryToGetHandle(characterName string) string {
 
 // Save the alias list to a json file
 func SaveAliases() error {
-	fmt.Println("Saving to alias file...")
+	logger.Debugf(logger.Alias, "Saving to alias file...")
 	file, err := json.MarshalIndent(ActiveAliases, "", " ")
 	if err != nil {
 		return fmt.Errorf("SaveAliases(): failed to marshal ActiveAliases: %w", err)
@@ -258,7 +260,7 @@ func SaveAliases() error {
 		return fmt.Errorf("SaveAliases(): failed to write to ActiveAliases: %w", err)
 	}
 
-	fmt.Println("Alias save successful!")
+	logger.Debugf(logger.Alias, "Alias save successful!")
 
 	return nil
 
@@ -266,7 +268,7 @@ func SaveAliases() error {
 
 // Load in the alias list from a json file
 func ReadAliasesFromFile() error {
-	fmt.Println("Reading alias file...")
+	logger.Infof(logger.Alias, "Reading alias file...")
 	created, err := checkAliasFile()
 	if err != nil {
 		return fmt.Errorf("checkAliasFile(): %w", err)
@@ -285,7 +287,7 @@ func ReadAliasesFromFile() error {
 	}
 
 	if err == nil {
-		fmt.Println("Alias load successful!")
+		logger.Infof(logger.Alias, "Alias load successful!")
 	}
 	return nil
 }
@@ -294,7 +296,7 @@ func checkAliasFile() (bool, error) {
 	createdFile := false
 	// Check if the config.json file exists
 	if _, err := os.Stat("./alias.json"); os.IsNotExist(err) {
-		fmt.Println("Alias file not found, creating new one...")
+		logger.Warnf(logger.Alias, "Alias file not found, creating new one...")
 		err := SaveAliases()
 		if err != nil {
 			return false, fmt.Errorf("checkAliasFile(): SaveAliases(): %w", err)
@@ -306,9 +308,9 @@ func checkAliasFile() (bool, error) {
 
 // Get a list of all files inside EQpath that contain the string config.GuildName
 func GetGuildFiles() ([]string, error) {
-	EQpath, err := os.Getwd()
+	EQpath, err := config.GetEQDir()
 	if err != nil {
-		return nil, fmt.Errorf("GetGuildFiles(): os.Getwd(): %w", err)
+		return nil, fmt.Errorf("GetGuildFiles(): %w", err)
 	}
 	files, err := ioutil.ReadDir(EQpath)
 	if err != nil {
@@ -317,7 +319,7 @@ func GetGuildFiles() ([]string, error) {
 	var guildFiles []string
 	for _, f := range files {
 		if strings.Contains(f.Name(), config.GuildName) {
-			guildFiles = append(guildFiles, f.Name())
+			guildFiles = append(guildFiles, filepath.Join(EQpath, f.Name()))
 		}
 	}
 	return guildFiles, nil
@@ -349,7 +351,7 @@ func getMostRecentFile() (string, error) {
 
 // Load in the alias list from a json file
 func ReadGuildMembers() error {
-	fmt.Println("Reading guild file...")
+	logger.Infof(logger.Alias, "Reading guild file...")
 
 	// Clear the Active Guild List
 	ActiveGuildMembers.List = []GuildMember{}
@@ -361,7 +363,7 @@ func ReadGuildMembers() error {
 	}
 
 	// Newest guild file located
-	fmt.Printf("Located guild file: %s\n", guildFile)
+	logger.Infof(logger.Alias, "Located guild file: %s", guildFile)
 
 	// Load the guild file
 	file, err := ioutil.ReadFile(guildFile)
@@ -370,7 +372,7 @@ func ReadGuildMembers() error {
 	}
 
 	// Guild file loaded
-	fmt.Println("Guild file loaded...")
+	logger.Debugf(logger.Alias, "Guild file loaded...")
 
 	// Iterate through the text file (file) and parse the guild members
 	lines := strings.Split(string(file), "\n")
@@ -402,12 +404,12 @@ func ReadGuildMembers() error {
 			ActiveGuildMembers.List = append(ActiveGuildMembers.List, guildMember)
 
 			// Guild Member imported
-			fmt.Printf("Imported guild member: %s...\n", guildMember.Name)
+			logger.Debugf(logger.Alias, "Imported guild member: %s...", guildMember.Name)
 		}
 	}
 
 	if err == nil {
-		fmt.Println("Guild load successful!")
+		logger.Infof(logger.Alias, "Guild load successful!")
 	}
 	return nil
 }
@@ -415,7 +417,7 @@ func ReadGuildMembers() error {
 // Generage a new alias list from the loaded Guild List
 func GenerateAliasListFromGuildList() error {
 	// Flushing active alias list
-	fmt.Println("Flushing active alias list...")
+	logger.Infof(logger.Alias, "Flushing active alias list...")
 	ActiveAliases.List = []Alias{}
 
 	// Read in guild members
@@ -6,11 +6,15 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Valorith/EQRaidAssist/core"
+	"github.com/Valorith/EQRaidAssist/eqlog"
 	"github.com/Valorith/EQRaidAssist/loadFile"
+	"github.com/Valorith/EQRaidAssist/logger"
 )
 
 var (
@@ -23,6 +27,16 @@ var (
 	LootWebHookUrl   string
 	AttendWebHookUrl string
 	GuildName        string
+	LootPolicies     map[string]LootPolicy
+	Bosses           []string             // Names of the NPCs whose deaths are recorded as raid encounters
+	EncounterWindow  int                  // Minutes after a boss kill during which awarded loot is linked to the encounter
+	CheckinKeyword   string               // Keyword raiders type in guild chat or raid say to check in
+	RaidMode         string               // How raids are started and ended, RaidModeAuto or RaidModeManual
+	LogOffsets       map[string]LogOffset // Last processed position of each log file, keyed by file name (saved to logOffsetsFile)
+	EQDir            string               // Root EverQuest directory, defaults to the working directory
+	LogsDir          string               // Character logs directory, defaults to <EQDir>/Logs
+	RaidLogsDir      string               // Organized raid dumps directory, defaults to <EQDir>/RaidLogs
+	SavedRaidsDir    string               // Saved raid files directory, defaults to <EQDir>/SavedRaids
 	// Private variables
 	config *configStruct
 	mu     sync.RWMutex
@@ -37,19 +51,72 @@ func ResetData() {
 	LootWebHookUrl = ""
 	AttendWebHookUrl = ""
 	GuildName = ""
+	LootPolicies = nil
+	Bosses = nil
+	EncounterWindow = 0
+	CheckinKeyword = ""
+	RaidMode = ""
+	LogOffsets = nil
+	EQDir = ""
+	LogsDir = ""
+	RaidLogsDir = ""
+	SavedRaidsDir = ""
 	config = nil
 
 }
 
 type configStruct struct {
-	MONGODB_USERNAME string `json:"mongodbUsername"`
-	MONGODB_PASSWORD string `json:"mongodbPassword"`
-	Token            string `json:"Token"`
-	BotPrefix        string `json:"BotPrefix"`
-	LootChannel      string `json:"LootChannel"`
-	LootWebHookUrl   string `json:"LootWebHookUrl"`
-	AttendWebHookUrl string `json:"AttendWebHookUrl"`
-	GuildName        string `json:"GuildName"`
+	MONGODB_USERNAME string                `json:"mongodbUsername"`
+	MONGODB_PASSWORD string                `json:"mongodbPassword"`
+	Token            string                `json:"Token"`
+	BotPrefix        string                `json:"BotPrefix"`
+	LootChannel      string                `json:"LootChannel"`
+	LootWebHookUrl   string                `json:"LootWebHookUrl"`
+	AttendWebHookUrl string                `json:"AttendWebHookUrl"`
+	GuildName        string                `json:"GuildName"`
+	LootPolicies     map[string]LootPolicy `json:"LootPolicies"`
+	Bosses           []string              `json:"Bosses"`
+	EncounterWindow  int                   `json:"EncounterWindow"`
+	CheckinKeyword   string                `json:"CheckinKeyword"`
+	RaidMode         string                `json:"RaidMode"`
+	EQDir            string                `json:"EQDir"`
+	LogsDir          string                `json:"LogsDir"`
+	RaidLogsDir      string                `json:"RaidLogsDir"`
+	SavedRaidsDir    string                `json:"SavedRaidsDir"`
+}
+
+// Records how far into a log file has been processed
+type LogOffset struct {
+	Offset int64  `json:"offset"` // Byte offset of the next unprocessed line
+	Head   string `json:"head"`   // First line of the file, used to detect when the file was replaced
+}
+
+// File in the EQ directory the log offsets are saved to. They change constantly while logs are scanned,
+// so they are kept out of config.json
+const logOffsetsFile = "logoffsets.json"
+
+// Encounter window used when none has been configured
+const defaultEncounterWindow = 10
+
+// Raid modes
+const (
+	RaidModeAuto   = "auto"   // Raids start with the first raid dump and end when the scanner stops
+	RaidModeManual = "manual" // Raids are started and ended with the raid commands, raid dumps only check in
+)
+
+// Determines how loot awarded through a given distribution method is handled
+type LootPolicy struct {
+	Announce bool `json:"announce"` // Post the award to the loot webhook
+	Count    bool `json:"count"`    // Count the award against the player
+}
+
+// Policies applied to loot methods that have not been configured
+var defaultLootPolicies = map[string]LootPolicy{
+	eqlog.MethodCouncil:       {Announce: true, Count: true},
+	eqlog.MethodAssigned:      {Announce: true, Count: true},
+	eqlog.MethodRandom:        {Announce: true, Count: false},
+	eqlog.MethodMasterLooting: {Announce: false, Count: false},
+	eqlog.MethodCorpse:        {Announce: false, Count: true},
 }
 
 func GetBotToken() (string, error) {
@@ -244,10 +311,396 @@ func SetAttendWebHookUrl(url string) error {
 	return nil
 }
 
-func GetPossibleServerNames(charName string) ([]string, error) {
+// Returns the policy for the provided loot method
+func GetLootPolicy(method string) LootPolicy {
+	mu.RLock()
+	defer mu.RUnlock()
+	if policy, ok := LootPolicies[method]; ok {
+		return policy
+	}
+	return defaultLootPolicies[method]
+}
+
+// Sets the policy for the provided loot method.
+// Valid policies are: none, announce, count or both
+func SetLootPolicy(method, policyName string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := defaultLootPolicies[method]; !ok {
+		return fmt.Errorf("SetLootPolicy(): unknown loot method: %s", method)
+	}
+	var policy LootPolicy
+	switch policyName {
+	case "none":
+	case "announce":
+		policy.Announce = true
+	case "count":
+		policy.Count = true
+	case "both":
+		policy.Announce = true
+		policy.Count = true
+	default:
+		return fmt.Errorf("SetLootPolicy(): invalid policy: %s (expected none, announce, count or both)", policyName)
+	}
+	if LootPolicies == nil {
+		LootPolicies = make(map[string]LootPolicy)
+	}
+	LootPolicies[method] = policy
+	PrepareToSaveConfig()
+	config.LootPolicies = LootPolicies
+	err := SaveConfig()
+	if err != nil {
+		return fmt.Errorf("SetLootPolicy(): %w", err)
+	}
+	return nil
+}
+
+// Returns true if the provided NPC name is on the boss list
+func IsBoss(name string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return isBoss(name)
+}
+
+func isBoss(name string) bool {
+	for _, boss := range Bosses {
+		if strings.EqualFold(boss, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func GetBosses() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]string{}, Bosses...)
+}
+
+// Adds the provided NPC name to the boss list
+func AddBoss(name string) error {
+	if name == "" {
+		return fmt.Errorf("AddBoss(): provided boss name is invalid")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if isBoss(name) {
+		return fmt.Errorf("AddBoss(): %s is already on the boss list", name)
+	}
+	Bosses = append(Bosses, name)
+	PrepareToSaveConfig()
+	config.Bosses = Bosses
+	err := SaveConfig()
+	if err != nil {
+		return fmt.Errorf("AddBoss(): %w", err)
+	}
+	return nil
+}
+
+// Removes the provided NPC name from the boss list
+func RemoveBoss(name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	for index, boss := range Bosses {
+		if strings.EqualFold(boss, name) {
+			Bosses = append(Bosses[:index], Bosses[index+1:]...)
+			PrepareToSaveConfig()
+			config.Bosses = Bosses
+			err := SaveConfig()
+			if err != nil {
+				return fmt.Errorf("RemoveBoss(): %w", err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("RemoveBoss(): %s is not on the boss list", name)
+}
+
+// Returns the window after a boss kill during which awarded loot is linked to the encounter
+func GetEncounterWindow() time.Duration {
+	mu.RLock()
+	defer mu.RUnlock()
+	if EncounterWindow <= 0 {
+		return defaultEncounterWindow * time.Minute
+	}
+	return time.Duration(EncounterWindow) * time.Minute
+}
+
+// Sets the encounter window, in minutes
+func SetEncounterWindow(minutes int) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if minutes <= 0 {
+		return fmt.Errorf("SetEncounterWindow(): provided window is invalid: %d", minutes)
+	}
+	EncounterWindow = minutes
+	PrepareToSaveConfig()
+	config.EncounterWindow = minutes
+	err := SaveConfig()
+	if err != nil {
+		return fmt.Errorf("SetEncounterWindow(): %w", err)
+	}
+	return nil
+}
+
+func GetCheckinKeyword() (string, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if CheckinKeyword == "" {
+		return "", fmt.Errorf("check-in keyword not set")
+	}
+	return CheckinKeyword, nil
+}
+
+func SetCheckinKeyword(keyword string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if keyword == "" {
+		return fmt.Errorf("SetCheckinKeyword(): provided keyword is invalid")
+	}
+	PrepareToSaveConfig()
+	config.CheckinKeyword = keyword
+	CheckinKeyword = keyword
+	err := SaveConfig()
+	if err != nil {
+		return fmt.Errorf("SetCheckinKeyword(): %w", err)
+	}
+	return nil
+}
+
+// Returns the raid mode, RaidModeAuto when none has been configured
+func GetRaidMode() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if RaidMode == "" {
+		return RaidModeAuto
+	}
+	return RaidMode
+}
+
+// Sets the raid mode, auto or manual
+func SetRaidMode(mode string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	mode = strings.ToLower(mode)
+	if mode != RaidModeAuto && mode != RaidModeManual {
+		return fmt.Errorf("SetRaidMode(): invalid mode: %s (expected %s or %s)", mode, RaidModeAuto, RaidModeManual)
+	}
+	PrepareToSaveConfig()
+	config.RaidMode = mode
+	RaidMode = mode
+	err := SaveConfig()
+	if err != nil {
+		return fmt.Errorf("SetRaidMode(): %w", err)
+	}
+	return nil
+}
+
+// Returns the saved offset for the provided log file name
+func GetLogOffset(fileName string) (LogOffset, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	offset, ok := LogOffsets[fileName]
+	return offset, ok
+}
+
+// Saves the offset for the provided log file name to the log offsets file
+func SetLogOffset(fileName string, offset LogOffset) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if LogOffsets == nil {
+		LogOffsets = make(map[string]LogOffset)
+	}
+	if LogOffsets[fileName] == offset {
+		return nil
+	}
+	LogOffsets[fileName] = offset
+	file, err := json.MarshalIndent(LogOffsets, "", " ")
+	if err != nil {
+		return fmt.Errorf("SetLogOffset(): failed to marshal log offsets: %w", err)
+	}
+	EQpath, err := eqDir()
+	if err != nil {
+		return fmt.Errorf("SetLogOffset(): %w", err)
+	}
+	err = ioutil.WriteFile(filepath.Join(EQpath, logOffsetsFile), file, 0644)
+	if err != nil {
+		return fmt.Errorf("SetLogOffset(): failed to write log offsets: %w", err)
+	}
+	return nil
+}
+
+// Loads the log offsets file of the EQ directory, a missing file holds no offsets
+func readLogOffsets() (map[string]LogOffset, error) {
+	offsets := make(map[string]LogOffset)
+	EQpath, err := eqDir()
+	if err != nil {
+		return nil, fmt.Errorf("readLogOffsets(): %w", err)
+	}
+	file, err := ioutil.ReadFile(filepath.Join(EQpath, logOffsetsFile))
+	if os.IsNotExist(err) {
+		return offsets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("readLogOffsets(): ioutil.ReadFile(): %w", err)
+	}
+	err = json.Unmarshal(file, &offsets)
+	if err != nil {
+		return nil, fmt.Errorf("readLogOffsets(): json.Unmarshal(): %w", err)
+	}
+	return offsets, nil
+}
+
+// Returns the known loot methods
+func GetLootMethods() []string {
+	methods := []string{}
+	for method := range defaultLootPolicies {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// Returns the root EverQuest directory
+func GetEQDir() (string, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return eqDir()
+}
+
+func eqDir() (string, error) {
+	if EQDir != "" {
+		return EQDir, nil
+	}
 	EQpath, err := os.Getwd()
 	if err != nil {
-		return []string{}, fmt.Errorf("os.Getwd(): %w", err)
+		return "", fmt.Errorf("eqDir(): os.Getwd(): %w", err)
+	}
+	return EQpath, nil
+}
+
+// Returns the directory holding the character logs
+func GetLogsDir() (string, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return subDir(LogsDir, "Logs")
+}
+
+// Returns the directory the raid dumps are organized into
+func GetRaidLogsDir() (string, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return subDir(RaidLogsDir, "RaidLogs")
+}
+
+// Returns the directory raids are saved to
+func GetSavedRaidsDir() (string, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return subDir(SavedRaidsDir, "SavedRaids")
+}
+
+// Resolves a configured directory override. Relative overrides are relative to the EQ directory,
+// no override uses the default folder name inside the EQ directory
+func subDir(override, defaultName string) (string, error) {
+	if filepath.IsAbs(override) {
+		return override, nil
+	}
+	EQpath, err := eqDir()
+	if err != nil {
+		return "", fmt.Errorf("subDir(): %w", err)
+	}
+	if override == "" {
+		override = defaultName
+	}
+	return filepath.Join(EQpath, override), nil
+}
+
+// Sets the root EverQuest directory, an empty value reverts to the working directory
+func SetEQDir(dir string) error {
+	if dir != "" {
+		err := checkDir(dir)
+		if err != nil {
+			return fmt.Errorf("SetEQDir(): %w", err)
+		}
+	}
+	mu.Lock()
+	PrepareToSaveConfig()
+	EQDir = dir
+	config.EQDir = dir
+	// The log offsets are kept in the EQ directory
+	offsets, err := readLogOffsets()
+	if err != nil {
+		logger.Warnf(logger.Config, "SetEQDir(): %s, log offsets reset", err)
+		offsets = make(map[string]LogOffset)
+	}
+	LogOffsets = offsets
+	mu.Unlock()
+	err = SaveConfig()
+	if err != nil {
+		return fmt.Errorf("SetEQDir(): %w", err)
+	}
+	return nil
+}
+
+// Sets the character logs directory, an empty value reverts to <EQDir>/Logs
+func SetLogsDir(dir string) error {
+	mu.Lock()
+	PrepareToSaveConfig()
+	LogsDir = dir
+	config.LogsDir = dir
+	mu.Unlock()
+	err := SaveConfig()
+	if err != nil {
+		return fmt.Errorf("SetLogsDir(): %w", err)
+	}
+	return nil
+}
+
+// Sets the raid dumps directory, an empty value reverts to <EQDir>/RaidLogs
+func SetRaidLogsDir(dir string) error {
+	mu.Lock()
+	PrepareToSaveConfig()
+	RaidLogsDir = dir
+	config.RaidLogsDir = dir
+	mu.Unlock()
+	err := SaveConfig()
+	if err != nil {
+		return fmt.Errorf("SetRaidLogsDir(): %w", err)
+	}
+	return nil
+}
+
+// Sets the saved raids directory, an empty value reverts to <EQDir>/SavedRaids
+func SetSavedRaidsDir(dir string) error {
+	mu.Lock()
+	PrepareToSaveConfig()
+	SavedRaidsDir = dir
+	config.SavedRaidsDir = dir
+	mu.Unlock()
+	err := SaveConfig()
+	if err != nil {
+		return fmt.Errorf("SetSavedRaidsDir(): %w", err)
+	}
+	return nil
+}
+
+// Returns an error if the path is not an existing directory
+func checkDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("checkDir(): os.Stat(): %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("checkDir(): %s is not a directory", dir)
+	}
+	return nil
+}
+
+func GetPossibleServerNames(charName string) ([]string, error) {
+	EQpath, err := GetEQDir()
+	if err != nil {
+		return []string{}, fmt.Errorf("GetPossibleServerNames(): %w", err)
 	}
 	//logsFolder := EQpath + "\\Logs"
 	//fmt.Println("Loading Players from: ", EQpath)
@@ -273,7 +726,6 @@ func GetPossibleServerNames(charName string) ([]string, error) {
 	})
 
 	if filePathError != nil {
-		fmt.Println(filePathError)
 		return []string{}, fmt.Errorf("filepath.Walk: %w", filePathError)
 	}
 
@@ -291,7 +743,7 @@ func sliceContains(s []string, str string) bool {
 }
 
 func ReadConfig() error {
-	fmt.Println("Reading config file...")
+	logger.Debugf(logger.Config, "Reading config file...")
 	created, err := checkConfigFile()
 	if err != nil {
 		return fmt.Errorf("checkConfigFile(): %w", err)
@@ -313,51 +765,89 @@ func ReadConfig() error {
 
 	MONGODB_USERNAME = config.MONGODB_USERNAME
 	if MONGODB_USERNAME == "" {
-		fmt.Println("MONGODB_USERNAME not set in config.json...")
+		logger.Debugf(logger.Config, "MONGODB_USERNAME not set in config.json...")
 	} else {
-		fmt.Println("MONGODB_USERNAME loaded from config.json...")
+		logger.Debugf(logger.Config, "MONGODB_USERNAME loaded from config.json...")
 	}
 	MONGODB_PASSWORD = config.MONGODB_PASSWORD
 	if MONGODB_USERNAME == "" {
-		fmt.Println("MONGODB_PASSWORD not set in config.json...")
+		logger.Debugf(logger.Config, "MONGODB_PASSWORD not set in config.json...")
 	} else {
-		fmt.Println("MONGODB_PASSWORD loaded from config.json...")
+		logger.Debugf(logger.Config, "MONGODB_PASSWORD loaded from config.json...")
 	}
 	Token = config.Token
 	if Token == "" {
-		fmt.Println("Token not set in config.json...")
+		logger.Debugf(logger.Config, "Token not set in config.json...")
 	} else {
-		fmt.Println("Token loaded from config.json...")
+		logger.Debugf(logger.Config, "Token loaded from config.json...")
 	}
 	BotPrefix = config.BotPrefix
 	if BotPrefix == "" {
-		fmt.Println("BotPrefix not set in config.json...")
+		logger.Debugf(logger.Config, "BotPrefix not set in config.json...")
 	} else {
-		fmt.Println("BotPrefix loaded from config.json...")
+		logger.Debugf(logger.Config, "BotPrefix loaded from config.json...")
 	}
 	LootChannel = config.LootChannel
 	if LootChannel == "" {
-		fmt.Println("LootChannel not set in config.json...")
+		logger.Debugf(logger.Config, "LootChannel not set in config.json...")
 	} else {
-		fmt.Println("LootChannel loaded from config.json...")
+		logger.Debugf(logger.Config, "LootChannel loaded from config.json...")
 	}
 	LootWebHookUrl = config.LootWebHookUrl
 	if LootWebHookUrl == "" {
-		fmt.Println("LootWebHookUrl not set in config.json...")
+		logger.Debugf(logger.Config, "LootWebHookUrl not set in config.json...")
 	} else {
-		fmt.Println("LootWebHookUrl loaded from config.json...")
+		logger.Debugf(logger.Config, "LootWebHookUrl loaded from config.json...")
 	}
 	AttendWebHookUrl = config.AttendWebHookUrl
 	if AttendWebHookUrl == "" {
-		fmt.Println("AttendWebHookUrl not set in config.json...")
+		logger.Debugf(logger.Config, "AttendWebHookUrl not set in config.json...")
 	} else {
-		fmt.Println("AttendWebHookUrl loaded from config.json...")
+		logger.Debugf(logger.Config, "AttendWebHookUrl loaded from config.json...")
 	}
 	GuildName = config.GuildName
 	if GuildName == "" {
-		fmt.Println("GuildName not set in config.json...")
+		logger.Debugf(logger.Config, "GuildName not set in config.json...")
+	} else {
+		logger.Debugf(logger.Config, "GuildName loaded from config.json...")
+	}
+	LootPolicies = config.LootPolicies
+	if len(LootPolicies) == 0 {
+		logger.Debugf(logger.Config, "LootPolicies not set in config.json, using defaults...")
+	} else {
+		logger.Debugf(logger.Config, "LootPolicies loaded from config.json...")
+	}
+	Bosses = config.Bosses
+	if len(Bosses) == 0 {
+		logger.Debugf(logger.Config, "Bosses not set in config.json...")
 	} else {
-		fmt.Println("GuildName loaded from config.json...")
+		logger.Debugf(logger.Config, "%d Bosses loaded from config.json...", len(Bosses))
+	}
+	EncounterWindow = config.EncounterWindow
+	if EncounterWindow == 0 {
+		logger.Debugf(logger.Config, "EncounterWindow not set in config.json, using default...")
+	} else {
+		logger.Debugf(logger.Config, "EncounterWindow loaded from config.json...")
+	}
+	CheckinKeyword = config.CheckinKeyword
+	if CheckinKeyword == "" {
+		logger.Debugf(logger.Config, "CheckinKeyword not set in config.json...")
+	} else {
+		logger.Debugf(logger.Config, "CheckinKeyword loaded from config.json...")
+	}
+	RaidMode = config.RaidMode
+	EQDir = config.EQDir
+	if EQDir == "" {
+		logger.Debugf(logger.Config, "EQDir not set in config.json, using the working directory...")
+	} else {
+		logger.Debugf(logger.Config, "EQDir loaded from config.json...")
+	}
+	LogsDir = config.LogsDir
+	RaidLogsDir = config.RaidLogsDir
+	SavedRaidsDir = config.SavedRaidsDir
+	LogOffsets, err = readLogOffsets()
+	if err != nil {
+		return fmt.Errorf("ReadConfig(): %w", err)
 	}
 
 	// Organize Raid Dump Files into subfolder
@@ -367,7 +857,7 @@ func ReadConfig() error {
 	}
 
 	if err == nil {
-		fmt.Println("Config load successful!")
+		logger.Infof(logger.Config, "Config load successful!")
 	}
 
 	return nil
@@ -378,7 +868,7 @@ func checkConfigFile() (bool, error) {
 	createdFile := false
 	// Check if the config.json file exists
 	if _, err := os.Stat("./config.json"); os.IsNotExist(err) {
-		fmt.Println("Config file not found, creating new one...")
+		logger.Infof(logger.Config, "Config file not found, creating new one...")
 		err := SaveConfig()
 		if err != nil {
 			return false, fmt.Errorf("checkConfigFile(): SaveConfig(): %w", err)
@@ -389,7 +879,7 @@ func checkConfigFile() (bool, error) {
 }
 
 func SaveConfig() error {
-	fmt.Println("Saving to config file...")
+	logger.Debugf(logger.Config, "Saving to config file...")
 	PrepareToSaveConfig()
 	file, err := json.MarshalIndent(config, "", " ")
 	if err != nil {
@@ -401,7 +891,7 @@ func SaveConfig() error {
 		return fmt.Errorf("SaveConfig(): failed to write to config: %w", err)
 	}
 
-	fmt.Println("Config save successful!")
+	logger.Debugf(logger.Config, "Config save successful!")
 
 	return nil
 
@@ -426,32 +916,38 @@ func PrepareToSaveConfig() bool {
 // Organize raid dump files into a RaidLogs subfolder
 func OrganizeRaidDumps() error {
 	//fmt.Println("Starting file organization...")
-	// Get the directory of the current executable
-	EQpath, err := os.Getwd()
+	// Get the EverQuest directory, where the client writes its raid dumps
+	EQpath, err := GetEQDir()
 	if err != nil {
-		return fmt.Errorf("organizeRaidDumps(): os.getwd: %w", err)
+		return fmt.Errorf("organizeRaidDumps(): %w", err)
 	}
 
 	// Ensure that the RaidLog folder exists
-	raidLogsFolder := EQpath + "\\RaidLogs"
+	raidLogsFolder, err := GetRaidLogsDir()
+	if err != nil {
+		return fmt.Errorf("organizeRaidDumps(): %w", err)
+	}
 	raidLogsFolderExists, err := loadFile.FileExists(raidLogsFolder)
 	if err != nil {
 		return fmt.Errorf("organizeRaidDumps(): loadFile.FileExists: %w", err)
 	}
 	if !raidLogsFolderExists {
-		os.Mkdir(raidLogsFolder, 0777)
-		fmt.Printf("Raid Log folder does not exist. Creating: %s\n", raidLogsFolder)
+		os.MkdirAll(raidLogsFolder, 0777)
+		logger.Infof(logger.Config, "Raid Log folder does not exist. Creating: %s", raidLogsFolder)
 	}
 
 	// Ensure that the SavedRaids folder exists
-	savedRaidsFolder := EQpath + "\\SavedRaids"
+	savedRaidsFolder, err := GetSavedRaidsDir()
+	if err != nil {
+		return fmt.Errorf("organizeRaidDumps(): %w", err)
+	}
 	savedRaidsFolderExists, err := loadFile.FileExists(savedRaidsFolder)
 	if err != nil {
 		return fmt.Errorf("organizeRaidDumps(): loadFile.FileExists: %w", err)
 	}
 	if !savedRaidsFolderExists {
-		os.Mkdir(savedRaidsFolder, 0777)
-		fmt.Printf("SavedRaids folder does not exist. Creating: %s\n", savedRaidsFolder)
+		os.MkdirAll(savedRaidsFolder, 0777)
+		logger.Infof(logger.Config, "SavedRaids folder does not exist. Creating: %s", savedRaidsFolder)
 	}
 
 	// Get the list of raid dump files
@@ -461,16 +957,15 @@ func OrganizeRaidDumps() error {
 	}
 	//fmt.Printf("%d logs found that need to be moved...\n", len(raidDumpFileList))
 	// Loop through the raid dump files
-	for _, raidFilePath := range raidDumpFileList {
-		// Get the file name from the path
-		raidFileName := filepath.Base(raidFilePath)
+	for _, raidFileName := range raidDumpFileList {
+		raidFilePath := filepath.Join(EQpath, raidFileName)
 		// Move the file to the RaidLogs folder
-		newFilePath := raidLogsFolder + "\\" + raidFileName
+		newFilePath := filepath.Join(raidLogsFolder, raidFileName)
 		err := loadFile.MoveFile(raidFilePath, newFilePath)
 		if err != nil {
 			return fmt.Errorf("organizeRaidDumps(): copyFile: %w", err)
 		}
-		fmt.Printf("Moving file: %s ---> %s\n", raidFilePath, newFilePath)
+		logger.Infof(logger.Config, "Moving file: %s ---> %s", raidFilePath, newFilePath)
 	}
 	return nil
 }
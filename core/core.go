@@ -7,49 +7,39 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
-
-	"github.com/Valorith/EQRaidAssist/player"
+	"sync"
+	"time"
 )
 
 var (
-	Players   []*player.Player // Players detected within the raid dump file
-	Rebooting bool             = false
+	Rebooting bool = false
+	Replaying bool = false // Historical logs are being replayed, outside notifications are suppressed
 	KEY       string
+	clockMu   sync.RWMutex
+	clock     func() time.Time = time.Now
 )
 
-// Returns the current player cache
-func GetActivePlayers() []*player.Player {
-	return Players
-}
-
-func AddPlayer(p *player.Player) error {
-	if IsCachedPlayer(p.Name) {
-		return fmt.Errorf("player %s is already cached", p.Name)
-	}
-	Players = append(Players, p)
-	return nil
-}
-
-func ClearPlayers() {
-	Players = nil
-	fmt.Println("Cached players cleared...")
+// Returns the current time, or the simulated time while historical logs are being replayed
+func Now() time.Time {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return clock()
 }
 
-// Check if the provided characterName is in the list of players
-func IsCachedPlayer(characterName string) bool {
-	for _, p := range Players {
-		if p.Name == characterName {
-			return true
-		}
+// Replaces the clock returned by Now, nil restores the system clock
+func SetClock(now func() time.Time) {
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	if now == nil {
+		now = time.Now
 	}
-	return false
+	clock = now
 }
 
 func Encrypt(stringToEncrypt string, keyString string) (string, error) {
 
 	//Since the key is in string, we need to convert decode it to bytes
 	key, _ := hex.DecodeString(keyString)
-	fmt.Printf("[Debug] key length (%s->%s): %d\n", keyString, key, len(key))
 	plaintext := []byte(stringToEncrypt)
 
 	//Create a new Cipher Block from the key
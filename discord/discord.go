@@ -4,38 +4,94 @@ import (
 	"fmt"
 
 	"github.com/Valorith/EQRaidAssist/config"
+	"github.com/Valorith/EQRaidAssist/core"
 	"github.com/Valorith/EQRaidAssist/discordwh"
+	"github.com/Valorith/EQRaidAssist/events"
+	"github.com/Valorith/EQRaidAssist/logger"
+	"github.com/Valorith/EQRaidAssist/raid"
 )
 
+// Longest description discord accepts in an embed
+const maxEmbedDescription = 4096
+
+// Subscribes the discord announcements to the event bus
+func Subscribe() {
+	events.Subscribe("discord", events.DefaultBuffer, announce, events.RaidStarted, events.CheckIn, events.LootAwarded, events.RaidStopped)
+}
+
+// Posts the announcement of the event to its discord channel
+func announce(event events.Event) {
+	if event.Replay || event.Text == "" {
+		return
+	}
+	var err error
+	title, description := embedText(event)
+	switch event.Kind {
+	case events.RaidStarted:
+		err = SendEmbedMessage("New Raid Created: "+title, description, 2)
+	case events.CheckIn:
+		err = SendEmbedMessage("Raid Checkin: "+title, description, 2)
+	case events.RaidStopped:
+		err = SendEmbedMessage("Raid Ended: "+title, description, 2)
+	case events.LootAwarded:
+		if event.Item != nil && config.GetLootPolicy(event.Item.Method).Announce {
+			SendMessage(event.Text, 1)
+		}
+	}
+	if err != nil {
+		logger.Errorf(logger.Discord, "announce: %v", err)
+	}
+}
+
+// Returns the embed title and description of the event, from the name, tags and description of its raid
+func embedText(event events.Event) (string, string) {
+	snapshot, ok := event.Snapshot.(raid.Raid)
+	if !ok {
+		return event.Raid, event.Text
+	}
+	if snapshot.Description == "" {
+		return snapshot.Title(), event.Text
+	}
+	return snapshot.Title(), snapshot.Description + "\n\n" + event.Text
+}
+
 func SendMessage(m string, messageType int) {
 	var err error
+	var webhookURL string
+	if core.Replaying {
+		return
+	}
 	if messageType == 1 { // Loot Channel
-		discordwh.WebhookURL, err = config.GetLootWebHookUrl()
+		webhookURL, err = config.GetLootWebHookUrl()
 	} else if messageType == 2 { // Attendance Channel
-		discordwh.WebhookURL, err = config.GetAtendWebHookUrl()
+		webhookURL, err = config.GetAtendWebHookUrl()
 	}
 
 	if err != nil {
-		fmt.Println("discord: failed to get webhook url:", err)
+		logger.Errorf(logger.Discord, "discord: failed to get webhook url: %v", err)
 		return
 	}
-	discordwh.Say(m)
+	discordwh.Say(webhookURL, m)
 
 }
 
 func SendEmbedMessage(title, description string, messageType int) error {
 	var err error
+	var webhookURL string
 	var color uint32 = 3583291
 	name := "EQRaidAssist"
 	url := "https://www.clumsysworld.com/"
 	icon_url := "https://styles.redditmedia.com/t5_2rosz/styles/communityIcon_hvzzme5v9kz41.jpg"
+	if core.Replaying {
+		return nil
+	}
 	if messageType == 1 { // Loot Channel
-		discordwh.WebhookURL, err = config.GetLootWebHookUrl()
+		webhookURL, err = config.GetLootWebHookUrl()
 		if err != nil {
 			return fmt.Errorf("discord: failed to get loot webhook url: %s", err)
 		}
 	} else if messageType == 2 { // Attendance Channel
-		discordwh.WebhookURL, err = config.GetAtendWebHookUrl()
+		webhookURL, err = config.GetAtendWebHookUrl()
 		if err != nil {
 			return fmt.Errorf("discord: failed to get attendance webhook url: %s", err)
 		}
@@ -43,9 +99,28 @@ func SendEmbedMessage(title, description string, messageType int) error {
 	author := discordwh.Author{Name: name, URL: url, IconURL: icon_url}
 	embed := discordwh.Embed{Author: &author, Title: title, URL: url, Description: description, Color: color, Fields: nil, Thumbnail: nil, Image: nil, Footer: nil}
 	PO := discordwh.PostOptions{Username: name, AvatarURL: icon_url, Embeds: []discordwh.Embed{embed}}
-	err = discordwh.Post(PO)
+	err = discordwh.Post(webhookURL, PO)
 	if err != nil {
 		return fmt.Errorf("discord: failed to post embed: %v", err)
 	}
 	return nil
 }
+
+// Posts the lines as a code block, split over as many embeds as the description limit requires
+func SendEmbedTable(title string, lines []string, messageType int) error {
+	block := ""
+	for _, line := range lines {
+		if block != "" && len(block)+len(line)+len("```\n\n```") > maxEmbedDescription {
+			err := SendEmbedMessage(title, "```\n"+block+"```", messageType)
+			if err != nil {
+				return err
+			}
+			block = ""
+		}
+		block += line + "\n"
+	}
+	if block == "" {
+		return nil
+	}
+	return SendEmbedMessage(title, "```\n"+block+"```", messageType)
+}
@@ -1,7 +1,7 @@
 // Package discord is a go library to quickly send events to discord channels
 // To get started: Create a Webhook on the server, noting down the webhook URL.
-// Then, in your application set the webhook URL variable and then you can use `Say`
-// for a simple text message, or `Post` for a more complex message.
+// Then, in your application pass the webhook URL to `Say` for a simple text message,
+// or to `Post` for a more complex message.
 package discordwh
 
 import (
@@ -11,25 +11,12 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
-	"sync"
 )
 
-// WebhookURL your discord webhook URL
-var (
-	mu         sync.Mutex
-	WebhookURL string
-)
-
-func ResetData() {
-	mu.Lock()
-	defer mu.Unlock()
-	WebhookURL = ""
-}
-
 // Say sends the provided message to the channel for which the webhook is configured.
-// If WebhookURL is not set, this does nothing.
-func Say(message string) error {
-	return Post(PostOptions{Content: message})
+// If webhookURL is empty, this does nothing.
+func Say(webhookURL, message string) error {
+	return Post(webhookURL, PostOptions{Content: message})
 }
 
 // PostOptions describes all possible options for a post
@@ -82,8 +69,8 @@ type Footer struct {
 
 // Post will post a message to the channel for which the webhook is configured.
 // Unlike `discord.Say()`, Post gives you full control over the message.
-func Post(content PostOptions) error {
-	if WebhookURL == "" {
+func Post(webhookURL string, content PostOptions) error {
+	if webhookURL == "" {
 		return nil
 	}
 
@@ -92,7 +79,7 @@ func Post(content PostOptions) error {
 		return err
 	}
 
-	resp, err := http.Post(WebhookURL, "application/JSON", body)
+	resp, err := http.Post(webhookURL, "application/JSON", body)
 	if err != nil {
 		return err
 	}
@@ -112,8 +99,8 @@ type FileOptions struct {
 // UploadFile will post a message to the channel for which the webhook is configured
 // and attach the specified file to your message. Rich embeds are not supported and
 // will be ignored if any are specified.
-func UploadFile(content PostOptions, file FileOptions) error {
-	if WebhookURL == "" {
+func UploadFile(webhookURL string, content PostOptions, file FileOptions) error {
+	if webhookURL == "" {
 		return nil
 	}
 
@@ -135,7 +122,7 @@ func UploadFile(content PostOptions, file FileOptions) error {
 	}
 	w.Close()
 
-	req, err := http.NewRequest("POST", WebhookURL, &b)
+	req, err := http.NewRequest("POST", webhookURL, &b)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,231 @@
+// Package eqlog parses EverQuest log lines into typed events.
+// Each supported message is described by a single rule: an event kind and a
+// pattern whose named groups become the fields of the resulting event.
+package eqlog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Layout of the timestamp that prefixes every log line, ie: [Wed Feb 16 20:11:04 2022]
+const timestampLayout = "Mon Jan _2 15:04:05 2006"
+
+// Kind identifies the type of message an event was parsed from
+type Kind int
+
+const (
+	KindUnknown    Kind = iota // Line has a valid timestamp but matched no rule
+	KindLoot                   // Item distributed through the loot window
+	KindSlain                  // An NPC or player has been slain
+	KindZone                   // The character writing the log entered a zone
+	KindRoll                   // A player rolled a /random, the result follows in a KindRollResult line
+	KindRollResult             // Range and result of the preceding /random
+	KindChat                   // A message sent to the guild or raid channel
+	KindDeath                  // A player died, slain players are reported as KindSlain
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindLoot:
+		return "loot"
+	case KindSlain:
+		return "slain"
+	case KindZone:
+		return "zone"
+	case KindRoll:
+		return "roll"
+	case KindRollResult:
+		return "roll result"
+	case KindChat:
+		return "chat"
+	case KindDeath:
+		return "death"
+	default:
+		return "unknown"
+	}
+}
+
+// Loot distribution methods, as stored in the "method" field of loot events
+const (
+	MethodMasterLooting = "masterloot" // The master looter took the item
+	MethodAssigned      = "assigned"   // The master looter assigned the item
+	MethodRandom        = "random"     // The item was awarded by random roll
+	MethodCouncil       = "council"    // The item was awarded by the loot council
+	MethodCorpse        = "corpse"     // The item was looted directly from a corpse
+)
+
+// Returns a human readable description of the provided loot method
+func MethodDescription(method string) string {
+	switch method {
+	case MethodMasterLooting:
+		return "master looting"
+	case MethodAssigned:
+		return "the Master Looter"
+	case MethodRandom:
+		return "random roll"
+	case MethodCouncil:
+		return "the Loot Council"
+	case MethodCorpse:
+		return "a corpse"
+	default:
+		return method
+	}
+}
+
+// Event is a single parsed log line
+type Event struct {
+	Time    time.Time         // Time the line was written to the log
+	Kind    Kind              // Type of message
+	Message string            // Line text following the timestamp
+	Fields  map[string]string // Named values captured from the message
+}
+
+// Returns the named field of the event, or an empty string if it was not captured
+func (e Event) Field(name string) string {
+	return e.Fields[name]
+}
+
+// rule describes how to recognize one type of message
+type rule struct {
+	kind    Kind
+	pattern *regexp.Regexp
+	fixup   func(fields map[string]string) // Optional normalization of the captured fields
+	exclude *regexp.Regexp                 // Optional pattern of messages this rule must not match
+}
+
+// Rules are tried in order, the first match wins.
+// Loot events without a player field were looted by the character writing the log,
+// slain events without a killer field were killed by the character writing the log
+// chat events without a player field were sent by the character writing the log
+// and death events without a player field are deaths of the character writing the log.
+var rules = []rule{
+	{
+		kind:    KindLoot,
+		pattern: regexp.MustCompile(`^(?:--)?(?P<item>.+?) has been awarded to (?P<player>\S+) (?P<method>.+?)\.?(?:--)?$`),
+		fixup:   normalizeLootMethod,
+	},
+	{
+		kind:    KindLoot,
+		pattern: regexp.MustCompile(`^(?:--)?(?P<item>.+?) has been looted by the Master Loot(?:er)?\.?(?:--)?$`),
+		fixup: func(fields map[string]string) {
+			fields["method"] = MethodMasterLooting
+		},
+	},
+	{
+		kind:    KindLoot,
+		pattern: regexp.MustCompile(`^--You have looted (?:an? )?(?P<item>.+?) from (?P<corpse>.+?)'s corpse\.?--$`),
+		fixup:   setCorpseMethod,
+	},
+	{
+		kind:    KindLoot,
+		pattern: regexp.MustCompile(`^--(?P<player>\S+) has looted (?:an? )?(?P<item>.+?) from (?P<corpse>.+?)'s corpse\.?--$`),
+		fixup:   setCorpseMethod,
+	},
+	{
+		kind:    KindSlain,
+		pattern: regexp.MustCompile(`^You have slain (?P<target>.+?)!$`),
+	},
+	{
+		kind:    KindDeath,
+		pattern: regexp.MustCompile(`^You have been slain by (?P<killer>.+?)!$`),
+	},
+	{
+		kind:    KindDeath,
+		pattern: regexp.MustCompile(`^You died\.$`),
+	},
+	{
+		kind:    KindDeath,
+		pattern: regexp.MustCompile(`^(?P<player>\S+) died\.$`),
+	},
+	{
+		kind:    KindSlain,
+		pattern: regexp.MustCompile(`^(?P<target>.+?) has been slain by (?P<killer>.+?)!$`),
+	},
+	{
+		kind:    KindZone,
+		pattern: regexp.MustCompile(`^You have entered (?P<zone>.+?)\.$`),
+		exclude: regexp.MustCompile(`^You have entered an (?:area|Arena)`),
+	},
+	{
+		kind:    KindRoll,
+		pattern: regexp.MustCompile(`^\*\*A Magic Die is rolled by (?P<player>\S+?)\.$`),
+	},
+	{
+		kind:    KindRollResult,
+		pattern: regexp.MustCompile(`^(?:\*\*)?It could have been any number from (?P<low>\d+) to (?P<high>\d+), but this time it turned up a (?P<value>\d+)\.$`),
+	},
+	{
+		kind:    KindChat,
+		pattern: regexp.MustCompile(`^(?P<player>\S+) tells the (?P<channel>guild|raid),\s+'(?P<text>.*)'$`),
+	},
+	{
+		kind:    KindChat,
+		pattern: regexp.MustCompile(`^You (?:say to your|tell your) (?P<channel>guild|raid),\s+'(?P<text>.*)'$`),
+	},
+}
+
+// Parse converts a raw log line into an event.
+// An error is only returned when the line does not carry a valid timestamp,
+// lines that match no rule are returned as KindUnknown.
+func Parse(line string) (Event, error) {
+	eventTime, message, err := ParseTimestamp(line)
+	if err != nil {
+		return Event{}, fmt.Errorf("Parse(): %w", err)
+	}
+	event := Event{Time: eventTime, Kind: KindUnknown, Message: message}
+	for _, r := range rules {
+		match := r.pattern.FindStringSubmatch(message)
+		if match == nil || (r.exclude != nil && r.exclude.MatchString(message)) {
+			continue
+		}
+		event.Kind = r.kind
+		event.Fields = make(map[string]string)
+		for index, name := range r.pattern.SubexpNames() {
+			if name != "" {
+				event.Fields[name] = strings.TrimSpace(match[index])
+			}
+		}
+		if r.fixup != nil {
+			r.fixup(event.Fields)
+		}
+		break
+	}
+	return event, nil
+}
+
+// ParseTimestamp splits a log line into its timestamp and the remaining message
+func ParseTimestamp(line string) (time.Time, string, error) {
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, "[") {
+		return time.Time{}, "", fmt.Errorf("ParseTimestamp(): line has no timestamp: %q", line)
+	}
+	end := strings.Index(line, "]")
+	if end < 0 {
+		return time.Time{}, "", fmt.Errorf("ParseTimestamp(): unterminated timestamp: %q", line)
+	}
+	lineTime, err := time.ParseInLocation(timestampLayout, line[1:end], time.Local)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("ParseTimestamp(): time.Parse: %w", err)
+	}
+	return lineTime, strings.TrimSpace(line[end+1:]), nil
+}
+
+// Maps the award text of a loot message (ie: "by the Loot Council") to a loot method
+func normalizeLootMethod(fields map[string]string) {
+	method := strings.ToLower(fields["method"])
+	switch {
+	case strings.Contains(method, "master looter"):
+		fields["method"] = MethodAssigned
+	case strings.Contains(method, "random"):
+		fields["method"] = MethodRandom
+	case strings.Contains(method, "loot council"):
+		fields["method"] = MethodCouncil
+	}
+}
+
+func setCorpseMethod(fields map[string]string) {
+	fields["method"] = MethodCorpse
+}
@@ -0,0 +1,143 @@
+package eqlog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line   string
+		kind   Kind
+		fields map[string]string
+	}{
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] Cloak of Flames has been awarded to Valgor by the Loot Council.",
+			kind:   KindLoot,
+			fields: map[string]string{"item": "Cloak of Flames", "player": "Valgor", "method": MethodCouncil},
+		},
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] Cloak of Flames has been awarded to Valgor by random roll.",
+			kind:   KindLoot,
+			fields: map[string]string{"item": "Cloak of Flames", "player": "Valgor", "method": MethodRandom},
+		},
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] Cloak of Flames has been awarded to Valgor by the Master Looter.",
+			kind:   KindLoot,
+			fields: map[string]string{"item": "Cloak of Flames", "player": "Valgor", "method": MethodAssigned},
+		},
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] Cloak of Flames has been looted by the Master Looter.",
+			kind:   KindLoot,
+			fields: map[string]string{"item": "Cloak of Flames", "method": MethodMasterLooting},
+		},
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] --You have looted a Cloak of Flames from Lord Nagafen's corpse.--",
+			kind:   KindLoot,
+			fields: map[string]string{"item": "Cloak of Flames", "player": "", "corpse": "Lord Nagafen", "method": MethodCorpse},
+		},
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] --Valgor has looted an Efreeti War Axe from an efreeti lord's corpse.--",
+			kind:   KindLoot,
+			fields: map[string]string{"item": "Efreeti War Axe", "player": "Valgor", "corpse": "an efreeti lord", "method": MethodCorpse},
+		},
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] Lord Nagafen has been slain by Valgor!",
+			kind:   KindSlain,
+			fields: map[string]string{"target": "Lord Nagafen", "killer": "Valgor"},
+		},
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] You have slain Lady Vox!",
+			kind:   KindSlain,
+			fields: map[string]string{"target": "Lady Vox", "killer": ""},
+		},
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] You have entered Plane of Fear.",
+			kind:   KindZone,
+			fields: map[string]string{"zone": "Plane of Fear"},
+		},
+		{
+			line: "[Wed Feb 16 20:11:04 2022] You have entered an area where levitation effects do not function.",
+			kind: KindUnknown,
+		},
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] **A Magic Die is rolled by Valgor.",
+			kind:   KindRoll,
+			fields: map[string]string{"player": "Valgor"},
+		},
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] **It could have been any number from 0 to 333, but this time it turned up a 57.",
+			kind:   KindRollResult,
+			fields: map[string]string{"low": "0", "high": "333", "value": "57"},
+		},
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] It could have been any number from 0 to 333, but this time it turned up a 57.",
+			kind:   KindRollResult,
+			fields: map[string]string{"low": "0", "high": "333", "value": "57"},
+		},
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] Valgor tells the guild, 'x'",
+			kind:   KindChat,
+			fields: map[string]string{"player": "Valgor", "channel": "guild", "text": "x"},
+		},
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] Valgor tells the raid,  'x'",
+			kind:   KindChat,
+			fields: map[string]string{"player": "Valgor", "channel": "raid", "text": "x"},
+		},
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] You say to your guild, 'x'",
+			kind:   KindChat,
+			fields: map[string]string{"player": "", "channel": "guild", "text": "x"},
+		},
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] You have been slain by Lord Nagafen!",
+			kind:   KindDeath,
+			fields: map[string]string{"player": "", "killer": "Lord Nagafen"},
+		},
+		{
+			line:   "[Wed Feb 16 20:11:04 2022] Valgor died.",
+			kind:   KindDeath,
+			fields: map[string]string{"player": "Valgor", "killer": ""},
+		},
+		{
+			line: "[Wed Feb 16 20:11:04 2022] You say, 'Hail, Guard Ronthar'",
+			kind: KindUnknown,
+		},
+	}
+
+	for _, test := range tests {
+		event, err := Parse(test.line)
+		if err != nil {
+			t.Fatalf("Parse(%q): %s", test.line, err)
+		}
+		if event.Kind != test.kind {
+			t.Errorf("Parse(%q): kind = %s, expected %s", test.line, event.Kind, test.kind)
+		}
+		for name, value := range test.fields {
+			if event.Field(name) != value {
+				t.Errorf("Parse(%q): field %s = %q, expected %q", test.line, name, event.Field(name), value)
+			}
+		}
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	lineTime, message, err := ParseTimestamp("[Wed Feb 02 20:11:04 2022] You have entered The Plane of Fear.\r")
+	if err != nil {
+		t.Fatalf("ParseTimestamp: %s", err)
+	}
+	expected := time.Date(2022, time.February, 2, 20, 11, 4, 0, time.Local)
+	if !lineTime.Equal(expected) {
+		t.Errorf("ParseTimestamp: time = %s, expected %s", lineTime, expected)
+	}
+	if message != "You have entered The Plane of Fear." {
+		t.Errorf("ParseTimestamp: message = %q", message)
+	}
+
+	for _, line := range []string{"", "Wed Feb 16 20:11:04 2022", "[Wed Feb 16 20:11:04 2022", "[not a time] hello"} {
+		if _, _, err := ParseTimestamp(line); err == nil {
+			t.Errorf("ParseTimestamp(%q): expected an error", line)
+		}
+	}
+}
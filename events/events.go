@@ -0,0 +1,190 @@
+// Package events is an in-process publish/subscribe bus.
+// Each subscriber handles events on its own goroutine, fed through a bounded buffer.
+// Events published while a subscriber's buffer is full are dropped for that subscriber,
+// so a slow webhook cannot hold up log tailing. Subscribers that must see every event
+// (ie: the raid file and database writers) subscribe with SubscribeBlocking instead,
+// and publishers wait for room in their buffer.
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Valorith/EQRaidAssist/core"
+	"github.com/Valorith/EQRaidAssist/logger"
+	"github.com/Valorith/EQRaidAssist/player"
+)
+
+// Kind identifies the type of an event
+type Kind int
+
+const (
+	RaidStarted  Kind = iota // A raid was started, by a raid dump or the raid start command
+	CheckIn                  // A check-in was credited on the active raid
+	PlayerJoined             // A character appeared in the latest raid dump
+	PlayerLeft               // A character is missing from the latest raid dump
+	LootAwarded              // An item was awarded to a character
+	RaidStopped              // The active raid was ended
+	RaidPaused               // Recording the active raid was paused
+	RaidResumed              // Recording the paused raid was resumed
+	RaidUpdated              // Anything else recorded on the active raid (encounters, deaths, zones)
+)
+
+func (k Kind) String() string {
+	switch k {
+	case RaidStarted:
+		return "raid started"
+	case CheckIn:
+		return "check-in"
+	case PlayerJoined:
+		return "player joined"
+	case PlayerLeft:
+		return "player left"
+	case LootAwarded:
+		return "loot awarded"
+	case RaidStopped:
+		return "raid stopped"
+	case RaidPaused:
+		return "raid paused"
+	case RaidResumed:
+		return "raid resumed"
+	case RaidUpdated:
+		return "raid updated"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a single notification published on the bus
+type Event struct {
+	Kind     Kind
+	Time     time.Time        // Time the event was published, from core.Now
+	Raid     string           // Name of the raid the event belongs to
+	Player   string           // Character the event concerns, ie: the loot recipient or the raid dump owner
+	Text     string           // Announcement for the event, events without one are not announced
+	Item     *player.LootItem // Awarded item, for LootAwarded events
+	Replay   bool             // Published while historical logs are replayed, outside notifications are suppressed
+	Snapshot interface{}      // Copy of the raid taken when the event was published (a raid.Raid)
+}
+
+// Buffer size used by subscribers that do not provide one
+const DefaultBuffer = 64
+
+type subscriber struct {
+	name    string
+	kinds   []Kind     // Kinds of events handled, every kind when empty
+	queue   chan Event // Events waiting to be handled
+	block   bool       // Publish waits for room in the queue rather than dropping the event
+	mu      sync.Mutex
+	idle    *sync.Cond // Signaled when the subscriber has no pending events
+	pending int        // Events queued or being handled
+}
+
+var (
+	mu          sync.RWMutex
+	subscribers []*subscriber
+)
+
+// Removes every subscriber
+func ResetData() {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, sub := range subscribers {
+		close(sub.queue)
+	}
+	subscribers = nil
+}
+
+// Registers a handler for the provided kinds of events, or every kind if none are provided.
+// The handler runs on its own goroutine and receives events in the order they were published.
+// Up to buffer events are queued while the handler is busy
+func Subscribe(name string, buffer int, handler func(Event), kinds ...Kind) {
+	subscribe(name, buffer, false, handler, kinds)
+}
+
+// Registers a handler like Subscribe, but events are never dropped for it:
+// when its buffer is full, Publish waits until the handler catches up
+func SubscribeBlocking(name string, buffer int, handler func(Event), kinds ...Kind) {
+	subscribe(name, buffer, true, handler, kinds)
+}
+
+func subscribe(name string, buffer int, block bool, handler func(Event), kinds []Kind) {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	sub := &subscriber{name: name, kinds: kinds, queue: make(chan Event, buffer), block: block}
+	sub.idle = sync.NewCond(&sub.mu)
+	go func() {
+		for event := range sub.queue {
+			handler(event)
+			sub.done()
+		}
+	}()
+	mu.Lock()
+	defer mu.Unlock()
+	subscribers = append(subscribers, sub)
+}
+
+// Delivers the event to the subscribers of its kind, without waiting for them to handle it.
+// Only waits for room in the buffer of a blocking subscriber
+func Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = core.Now()
+	}
+	event.Replay = core.Replaying
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, sub := range subscribers {
+		if !sub.wants(event.Kind) {
+			continue
+		}
+		sub.mu.Lock()
+		sub.pending++
+		sub.mu.Unlock()
+		if sub.block {
+			sub.queue <- event
+			continue
+		}
+		select {
+		case sub.queue <- event:
+		default:
+			sub.done()
+			logger.Warnf(logger.Events, "%s is falling behind, %s event dropped", sub.name, event.Kind)
+		}
+	}
+}
+
+// Waits until every subscriber has handled the events published so far
+func Wait() {
+	mu.RLock()
+	subs := append([]*subscriber{}, subscribers...)
+	mu.RUnlock()
+	for _, sub := range subs {
+		sub.mu.Lock()
+		for sub.pending > 0 {
+			sub.idle.Wait()
+		}
+		sub.mu.Unlock()
+	}
+}
+
+func (sub *subscriber) wants(kind Kind) bool {
+	if len(sub.kinds) == 0 {
+		return true
+	}
+	for _, k := range sub.kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (sub *subscriber) done() {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	sub.pending--
+	if sub.pending == 0 {
+		sub.idle.Broadcast()
+	}
+}
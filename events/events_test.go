@@ -0,0 +1,70 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestPublish(t *testing.T) {
+	ResetData()
+	defer ResetData()
+	received := []string{}
+	Subscribe("loot", 0, func(event Event) {
+		received = append(received, event.Player)
+	}, LootAwarded)
+	Publish(Event{Kind: LootAwarded, Player: "Valgor"})
+	Publish(Event{Kind: CheckIn, Player: "Ignored"})
+	Publish(Event{Kind: LootAwarded, Player: "Leaver"})
+	Wait()
+	if len(received) != 2 || received[0] != "Valgor" || received[1] != "Leaver" {
+		t.Errorf("received %v, expected [Valgor Leaver]", received)
+	}
+}
+
+func TestPublishFullBuffer(t *testing.T) {
+	ResetData()
+	defer ResetData()
+	started := make(chan bool, 3)
+	release := make(chan bool)
+	handled := 0
+	Subscribe("slow", 1, func(event Event) {
+		started <- true
+		<-release
+		handled++
+	})
+	Publish(Event{Kind: RaidUpdated})
+	<-started                         // The first event is being handled
+	Publish(Event{Kind: RaidUpdated}) // Buffered
+	Publish(Event{Kind: RaidUpdated}) // Dropped, the buffer is full
+	close(release)
+	Wait()
+	if handled != 2 {
+		t.Errorf("handled %d events, expected 2", handled)
+	}
+}
+
+func TestPublishBlocking(t *testing.T) {
+	ResetData()
+	defer ResetData()
+	started := make(chan bool, 3)
+	release := make(chan bool)
+	handled := 0
+	SubscribeBlocking("slow", 1, func(event Event) {
+		started <- true
+		<-release
+		handled++
+	})
+	Publish(Event{Kind: RaidUpdated})
+	<-started                         // The first event is being handled
+	Publish(Event{Kind: RaidUpdated}) // Buffered
+	published := make(chan bool)
+	go func() {
+		Publish(Event{Kind: RaidStopped}) // Waits for room, the buffer is full
+		close(published)
+	}()
+	close(release)
+	<-published
+	Wait()
+	if handled != 3 {
+		t.Errorf("handled %d events, expected 3", handled)
+	}
+}
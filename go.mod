@@ -2,16 +2,20 @@ module github.com/Valorith/EQRaidAssist
 
 go 1.17
 
-require golang.org/x/net v0.0.0-20220127200216-cd36cc0744dd
+require (
+	github.com/fsnotify/fsnotify v1.5.1
+	github.com/hpcloud/tail v1.0.0
+	github.com/spf13/viper v1.10.1
+	go.mongodb.org/mongo-driver v1.8.3
+	golang.org/x/net v0.0.0-20220127200216-cd36cc0744dd
+)
 
 require (
 	github.com/bwmarrin/discordgo v0.23.2 // indirect
-	github.com/fsnotify/fsnotify v1.5.1 // indirect
 	github.com/go-stack/stack v1.8.0 // indirect
 	github.com/golang/snappy v0.0.1 // indirect
 	github.com/gorilla/websocket v1.4.0 // indirect
 	github.com/hashicorp/hcl v1.0.0 // indirect
-	github.com/hpcloud/tail v1.0.0 // indirect
 	github.com/joho/godotenv v1.4.0 // indirect
 	github.com/klauspost/compress v1.13.6 // indirect
 	github.com/magiconair/properties v1.8.5 // indirect
@@ -22,13 +26,11 @@ require (
 	github.com/spf13/cast v1.4.1 // indirect
 	github.com/spf13/jwalterweatherman v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	github.com/spf13/viper v1.10.1 // indirect
 	github.com/subosito/gotenv v1.2.0 // indirect
 	github.com/xdg-go/pbkdf2 v1.0.0 // indirect
 	github.com/xdg-go/scram v1.0.2 // indirect
 	github.com/xdg-go/stringprep v1.0.2 // indirect
 	github.com/youmark/pkcs8 v0.0.0-20181117223130-1be2e3e5546d // indirect
-	go.mongodb.org/mongo-driver v1.8.3 // indirect
 	golang.org/x/crypto v0.0.0-20220214200702-86341886e292 // indirect
 	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e // indirect
 	golang.org/x/sys v0.0.0-20220209214540-3681064d5158 // indirect
@@ -0,0 +1,260 @@
+// Package logger writes leveled, component tagged diagnostics to a rotating log file.
+// Messages that pass their component's level are written to the log file, those that also
+// pass the console level are echoed to the console, keeping the interactive prompt readable.
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a message
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Converts a level name (debug, info, warn or error) into a Level
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return 0, fmt.Errorf("ParseLevel(): invalid level: %s (expected debug, info, warn or error)", name)
+	}
+}
+
+// Components messages are tagged with
+const (
+	Scanner = "scanner"
+	Raid    = "raid"
+	Alias   = "alias"
+	MongoDB = "mongodb"
+	Discord = "discord"
+	Events  = "events"
+	Config  = "config"
+	Console = "console" // Not a component: the level messages must reach to be echoed to the console
+)
+
+// Components whose level can be set
+var components = []string{Scanner, Raid, Alias, MongoDB, Discord, Events, Config}
+
+const (
+	defaultLevel        = LevelInfo
+	defaultConsoleLevel = LevelWarn
+	maxFileSize         = 5 * 1024 * 1024 // Size at which the log file is rotated
+	maxBackups          = 3               // Number of rotated log files kept (ie: EQRaidAssist.log.1)
+)
+
+var (
+	mu           sync.Mutex
+	levels       = map[string]Level{}
+	consoleLevel = defaultConsoleLevel
+	filePath     string   // Path of the log file, empty until Init is called
+	file         *os.File // Open log file
+	fileSize     int64    // Current size of the log file
+)
+
+func ResetData() {
+	mu.Lock()
+	defer mu.Unlock()
+	levels = map[string]Level{}
+	consoleLevel = defaultConsoleLevel
+	if file != nil {
+		file.Close()
+	}
+	filePath = ""
+	file = nil
+	fileSize = 0
+}
+
+// Opens the log file at the provided path. Until it is called, messages are only written to the console
+func Init(path string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if file != nil {
+		file.Close()
+		file = nil
+	}
+	filePath = path
+	err := openFile()
+	if err != nil {
+		return fmt.Errorf("Init(): %w", err)
+	}
+	return nil
+}
+
+// Closes the log file
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+	if file != nil {
+		file.Close()
+		file = nil
+	}
+}
+
+// Sets the level of a component, "all" sets every component and "console" sets the console level
+func SetLevel(component string, level Level) error {
+	mu.Lock()
+	defer mu.Unlock()
+	component = strings.ToLower(component)
+	switch {
+	case component == Console:
+		consoleLevel = level
+	case component == "all":
+		for _, name := range components {
+			levels[name] = level
+		}
+	case isComponent(component):
+		levels[component] = level
+	default:
+		return fmt.Errorf("SetLevel(): invalid component: %s (expected %s, all or console)", component, strings.Join(components, ", "))
+	}
+	return nil
+}
+
+// Returns the level of every component, along with the console level
+func GetLevels() map[string]Level {
+	mu.Lock()
+	defer mu.Unlock()
+	out := map[string]Level{Console: consoleLevel}
+	for _, name := range components {
+		out[name] = levelOf(name)
+	}
+	return out
+}
+
+// Returns the names of the components, sorted
+func GetComponents() []string {
+	names := append([]string{}, components...)
+	sort.Strings(names)
+	return names
+}
+
+func Debugf(component, format string, args ...interface{}) {
+	write(component, LevelDebug, format, args...)
+}
+
+func Infof(component, format string, args ...interface{}) {
+	write(component, LevelInfo, format, args...)
+}
+
+func Warnf(component, format string, args ...interface{}) {
+	write(component, LevelWarn, format, args...)
+}
+
+func Errorf(component, format string, args ...interface{}) {
+	write(component, LevelError, format, args...)
+}
+
+// Writes the message to the log file and, if it passes the console level, the console
+func write(component string, level Level, format string, args ...interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+	if level < levelOf(component) {
+		return
+	}
+	message := strings.TrimRight(fmt.Sprintf(format, args...), "\n")
+	line := fmt.Sprintf("%s [%s] %s: %s\n", time.Now().Format("2006-01-02 15:04:05"), strings.ToUpper(level.String()), component, message)
+	if file != nil {
+		writeFile(line)
+	}
+	if level >= consoleLevel || file == nil {
+		fmt.Print(line)
+	}
+}
+
+func levelOf(component string) Level {
+	if level, ok := levels[component]; ok {
+		return level
+	}
+	return defaultLevel
+}
+
+func isComponent(component string) bool {
+	for _, name := range components {
+		if name == component {
+			return true
+		}
+	}
+	return false
+}
+
+// Appends the line to the log file, rotating it when it grows past maxFileSize
+func writeFile(line string) {
+	if fileSize+int64(len(line)) > maxFileSize {
+		err := rotate()
+		if err != nil {
+			fmt.Printf("logger: %s\n", err)
+			return
+		}
+	}
+	count, err := file.WriteString(line)
+	if err != nil {
+		fmt.Printf("logger: file.WriteString: %s\n", err)
+	}
+	fileSize += int64(count)
+}
+
+// Shifts the log files (EQRaidAssist.log -> EQRaidAssist.log.1 -> ...) and starts a new log file
+func rotate() error {
+	file.Close()
+	file = nil
+	os.Remove(fmt.Sprintf("%s.%d", filePath, maxBackups))
+	for index := maxBackups - 1; index >= 1; index-- {
+		os.Rename(fmt.Sprintf("%s.%d", filePath, index), fmt.Sprintf("%s.%d", filePath, index+1))
+	}
+	err := os.Rename(filePath, filePath+".1")
+	if err != nil {
+		return fmt.Errorf("rotate(): os.Rename: %w", err)
+	}
+	err = openFile()
+	if err != nil {
+		return fmt.Errorf("rotate(): %w", err)
+	}
+	return nil
+}
+
+func openFile() error {
+	var err error
+	file, err = os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("openFile(): os.OpenFile: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("openFile(): file.Stat: %w", err)
+	}
+	fileSize = info.Size()
+	return nil
+}
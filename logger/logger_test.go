@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	ResetData()
+	defer ResetData()
+	path := filepath.Join(t.TempDir(), "test.log")
+	err := Init(path)
+	if err != nil {
+		t.Fatalf("Init: %s", err)
+	}
+	err = SetLevel(Raid, LevelWarn)
+	if err != nil {
+		t.Fatalf("SetLevel: %s", err)
+	}
+	Infof(Raid, "hidden")
+	Warnf(Raid, "shown %d", 1)
+	Debugf(Scanner, "hidden")
+	Infof(Scanner, "shown %d", 2)
+	Close()
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("os.ReadFile: %s", err)
+	}
+	if strings.Contains(string(contents), "hidden") {
+		t.Errorf("messages below their component level were logged:\n%s", contents)
+	}
+	if !strings.Contains(string(contents), "[WARN] raid: shown 1") || !strings.Contains(string(contents), "[INFO] scanner: shown 2") {
+		t.Errorf("expected messages were not logged:\n%s", contents)
+	}
+	if SetLevel("nope", LevelInfo) == nil {
+		t.Errorf("SetLevel accepted an invalid component")
+	}
+}
+
+func TestRotate(t *testing.T) {
+	ResetData()
+	defer ResetData()
+	path := filepath.Join(t.TempDir(), "test.log")
+	err := Init(path)
+	if err != nil {
+		t.Fatalf("Init: %s", err)
+	}
+	SetLevel(Console, LevelError)
+	line := strings.Repeat("x", 1024)
+	for index := 0; index < (maxBackups+2)*maxFileSize/1024; index++ {
+		Infof(Scanner, line)
+	}
+	Close()
+
+	for index := 1; index <= maxBackups; index++ {
+		if _, err := os.Stat(path + "." + string(rune('0'+index))); err != nil {
+			t.Errorf("missing rotated log %d: %s", index, err)
+		}
+	}
+	if _, err := os.Stat(path + "." + string(rune('0'+maxBackups+1))); err == nil {
+		t.Errorf("more than %d rotated logs were kept", maxBackups)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxFileSize {
+		t.Errorf("log was not rotated: %v", err)
+	}
+}
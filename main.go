@@ -1,29 +1,46 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Valorith/EQRaidAssist/alias"
 	"github.com/Valorith/EQRaidAssist/config"
+	"github.com/Valorith/EQRaidAssist/core"
+	"github.com/Valorith/EQRaidAssist/discord"
+	"github.com/Valorith/EQRaidAssist/events"
+	"github.com/Valorith/EQRaidAssist/logger"
 	"github.com/Valorith/EQRaidAssist/mongodb"
 	"github.com/Valorith/EQRaidAssist/raid"
+	"github.com/Valorith/EQRaidAssist/roll"
 	"github.com/Valorith/EQRaidAssist/scanner"
 )
 
+// Diagnostics log file, rotated as it grows
+const logFileName = "EQRaidAssist.log"
+
 var (
-	commandsDisplayed bool = false
+	commandsDisplayed bool          = false
+	stdin             *bufio.Reader = bufio.NewReader(os.Stdin) // Buffered reader for user input
 )
 
 func main() {
+	defer mongodb.DisconnectALL() // Deferred first so pending database writes finish in close() before disconnecting
 	defer close()
 	var err error
 	var count int //scanline arg return count
 	var userInput string
 
-	defer mongodb.DisconnectALL()
+	// Write diagnostics to the log file, keeping the console for the prompt
+	err = logger.Init(logFileName)
+	if err != nil {
+		fmt.Printf("main: failed to open log file: %s\n", err)
+	}
 
 	// Load the config file
 	err = config.ReadConfig()
@@ -34,22 +51,32 @@ func main() {
 	// Initialize database connections
 	mongodb.Init()
 
+	// Save raids and post discord announcements as raid events are published
+	raid.Subscribe()
+	discord.Subscribe()
+
 	// Load the alias data from the database
 	err = alias.ActiveAliases.LoadFromDB()
 	if err != nil {
 		fmt.Printf("main: failed to load aliases: %s\n", err)
 	}
-	// Load the raid data from the database
+	// Load the raid data from the database, or from the saved raid files if it can't be reached
 	err = raid.AllRaids.LoadFromDB()
 	if err != nil {
 		fmt.Printf("main: failed to load raids: %s\n", err)
+		err = raid.AllRaids.LoadFromFiles()
+		if err != nil {
+			fmt.Printf("main: failed to load saved raid files: %s\n", err)
+		} else {
+			fmt.Printf("Loaded %d raids from the saved raid files\n", len(raid.AllRaids.RaidList))
+		}
 	}
 
 	for {
 		// If the character name is not set, request it
 		if !scanner.IsCharacterNameSet() {
 			fmt.Printf("Enter the character name that you want to monitor (first name only): ")
-			count, err = fmt.Scanln(&userInput)
+			userInput, _, _, count, err = readInput()
 			if err != nil {
 				fmt.Println("invalid name input:", err)
 				continue
@@ -73,7 +100,7 @@ func main() {
 		// If the server name is not set (infer failed), request it
 		if !scanner.IsServerNameSet() {
 			fmt.Printf("Enter your server short name: ")
-			count, err = fmt.Scanln(&userInput)
+			userInput, _, _, count, err = readInput()
 			if err != nil {
 				fmt.Println("invalid server input:", err)
 				continue
@@ -90,34 +117,102 @@ func main() {
 		}
 
 		if !commandsDisplayed {
+			// Pick up a raid left unfinished by a crash
+			offerRaidRecovery()
 			// Print the available commands to the user
 			printCommands()
 		}
 
 		var subCommand, value string
-		_, err = fmt.Scanln(&userInput, &subCommand, &value)
+		userInput, subCommand, value, count, err = readInput()
+		if err == io.EOF {
+			fmt.Println("command error: input closed")
+			return
+		}
 		if err != nil {
-			if !(err.Error() == "unexpected newline") { // Filter out this specific known error condition that is not of concern
-				fmt.Printf("command error: %s %s %s: %s\n", userInput, subCommand, value, err)
-			}
+			fmt.Printf("command error: %s %s %s: %s\n", userInput, subCommand, value, err)
+		}
+		if count == 0 {
+			continue
 		}
 		// Retrieve commands from user
 		go getUserInput(userInput, subCommand, value)
 	}
 }
 
+// Reads a line of user input. Returns the first two words, the remainder of the line
+// (allowing values that contain spaces) and the number of words read
+func readInput() (string, string, string, int, error) {
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", "", "", 0, err
+	}
+	words := strings.Fields(line)
+	input, subCommand, value := "", "", ""
+	if len(words) > 0 {
+		input = words[0]
+	}
+	if len(words) > 1 {
+		subCommand = words[1]
+	}
+	if len(words) > 2 {
+		value = strings.Join(words[2:], " ")
+	}
+	return input, subCommand, value, len(words), nil
+}
+
 // Print the available commands to the user
 func printCommands() {
 	commandsDisplayed = true
 	fmt.Printf("Commands:\nStart scanning raid file: 'start'\nStop scanning raid file: 'stop'\nExit application: 'exit' or 'quit'\n")
 	fmt.Printf("Load the most recent saved raid: 'get lastraid'\n")
 	fmt.Printf("Show current raid participants: 'get raid'\n")
+	fmt.Printf("Control the raid: 'raid start [name]', 'raid pause', 'raid resume', 'raid end', 'raid status'\n")
+	fmt.Printf("Resume a raid left unfinished by a crash: 'raid recover [file]'\n")
+	fmt.Printf("Name, describe or tag the open raid, or a saved raid by file name: 'raid name [file] <name>', 'raid describe [file] <text>', 'raid tag|untag [file] <tag>,<tag>'\n")
+	fmt.Printf("Browse the raid history: 'raids list [from] [to] [zone=<zone>] [tag=<tag>]', 'raids show <name or file>', 'raids player <name>'\n")
+	fmt.Printf("Show attendance per handle over the last 30, 60 and 90 days and lifetime, optionally posting it to discord: 'raids attendance [post]'\n")
+	fmt.Printf("Start raids with the first raid dump and end them with the scanner (auto), or only through the raid commands (manual): 'set raidmode <auto|manual>'\n")
+	fmt.Printf("Watch additional character logs: 'set watch <name>', 'set unwatch <name>', 'get characters'\n")
 	fmt.Printf("Reset all session data: 'set reset'\n")
+	fmt.Printf("Set the EverQuest folder: 'set eqdir <path>', override its folders with 'set logsdir|raidlogsdir|savedraidsdir <path>', show them with 'get paths'\n")
+	fmt.Printf("Set how a loot method is handled: 'set lootpolicy <council|assigned|random|masterloot|corpse>=<none|announce|count|both>'\n")
+	fmt.Printf("Add or remove a tracked boss: 'set boss <name>', 'set removeboss <name>'\n")
+	fmt.Printf("Show boss kills and their loot: 'get encounters'\n")
+	fmt.Printf("Show player deaths: 'get deaths'\n")
+	fmt.Printf("Show who joined, left, changed groups or levelled between raid dumps: 'get roster'\n")
+	fmt.Printf("Show the lines skipped in the latest raid dump: 'get parsereport'\n")
+	fmt.Printf("Change how much is logged to %s: 'set loglevel <scanner|raid|alias|mongodb|discord|events|config|all|console> <debug|info|warn|error>', 'get loglevel'\n", logFileName)
+	fmt.Printf("Let raiders check in by typing a keyword in guild or raid chat: 'set checkinkeyword <keyword>'\n")
+	fmt.Printf("Roll for loot: 'roll open <range> <item>', 'roll close <range>', 'roll list'\n")
+	fmt.Printf("Rebuild a missed raid from a saved log: 'replay <eqlog file> <from> <to>' (times as 2006-01-02T15:04)\n")
 	fmt.Printf("Get app variables: 'get <identifier>'\nSet app variables: 'set <identifier>'\n")
 	fmt.Println("-----------------")
 	fmt.Println("Enter a command:")
 }
 
+// Offers to resume the newest raid that was never ended, ie: because the application crashed
+func offerRaidRecovery() {
+	fileName, err := raid.FindUnfinishedRaid()
+	if err != nil {
+		fmt.Printf("main: failed to look for an unfinished raid: %s\n", err)
+		return
+	}
+	if fileName == "" {
+		return
+	}
+	fmt.Printf("Found an unfinished raid (%s), resume it? (y/n): ", fileName)
+	answer, _, _, _, err := readInput()
+	if err != nil || !strings.HasPrefix(strings.ToLower(answer), "y") {
+		fmt.Println("Raid not resumed, resume it later with 'raid recover'")
+		return
+	}
+	err = scanner.RecoverRaid(fileName)
+	if err != nil {
+		fmt.Printf("scanner.RecoverRaid(): %s\n", err)
+	}
+}
+
 // Attempt to infer what the server name is based on the character name and client files
 func inferServerName() {
 	// Attempt to infer the server name based upon the provided char name
@@ -137,7 +232,7 @@ func inferServerName() {
 		}
 		// Set the server to the selected server
 		fmt.Printf("Enter your server selection (1 - %d):\n", len(possibleServerNames))
-		count, err = fmt.Scanln(&userInput)
+		userInput, _, _, count, err = readInput()
 		if err != nil {
 			fmt.Println("invalid server input:", err)
 			return
@@ -184,8 +279,7 @@ func getUserInput(input, subcommand, value string) {
 		scanner.Stop()
 	case "exit":
 		fmt.Println("[Status] Exiting...")
-		config.SaveConfig()
-		os.Exit(0)
+		exit()
 	case "set":
 		switch subcommand {
 		case "server":
@@ -201,6 +295,26 @@ func getUserInput(input, subcommand, value string) {
 				fmt.Printf("getUserInput: invalid character name: %s->%s", value, err)
 			}
 
+			if scanner.IsRunning() {
+				scanner.Reboot()
+			}
+		case "watch":
+			fmt.Println("Watching the log of:", value)
+			err = scanner.AddCharacter(strings.Title(value))
+			if err != nil {
+				fmt.Printf("getUserInput: %s->%s\n", value, err)
+				return
+			}
+			if scanner.IsRunning() {
+				scanner.Reboot()
+			}
+		case "unwatch":
+			fmt.Println("No longer watching the log of:", value)
+			err = scanner.RemoveCharacter(strings.Title(value))
+			if err != nil {
+				fmt.Printf("getUserInput: %s->%s\n", value, err)
+				return
+			}
 			if scanner.IsRunning() {
 				scanner.Reboot()
 			}
@@ -240,6 +354,93 @@ func getUserInput(input, subcommand, value string) {
 			if err != nil {
 				fmt.Printf("getUserInput: %s->%s\n", value, err)
 			}
+		case "lootpolicy":
+			method, policy := value, ""
+			if index := strings.Index(value, "="); index >= 0 {
+				method, policy = value[:index], value[index+1:]
+			}
+			fmt.Printf("Setting loot policy for %s to: %s\n", method, policy)
+			err = config.SetLootPolicy(method, policy)
+			if err != nil {
+				fmt.Printf("getUserInput: %s->%s\n", value, err)
+			}
+		case "raidmode":
+			err := config.SetRaidMode(value)
+			if err != nil {
+				fmt.Printf("set raidmode error: %s\n", err)
+				return
+			}
+			fmt.Println("Raid mode set to:", config.GetRaidMode())
+		case "checkinkeyword":
+			fmt.Println("Setting check-in keyword to:", value)
+			err = config.SetCheckinKeyword(value)
+			if err != nil {
+				fmt.Printf("getUserInput: %s->%s\n", value, err)
+			}
+		case "boss":
+			fmt.Println("Adding boss:", value)
+			err = config.AddBoss(value)
+			if err != nil {
+				fmt.Printf("getUserInput: %s->%s\n", value, err)
+			}
+		case "removeboss":
+			fmt.Println("Removing boss:", value)
+			err = config.RemoveBoss(value)
+			if err != nil {
+				fmt.Printf("getUserInput: %s->%s\n", value, err)
+			}
+		case "encounterwindow":
+			fmt.Println("Setting encounter window (minutes) to:", value)
+			intValue, err := strconv.Atoi(value)
+			if err != nil {
+				fmt.Printf("getUserInput: invalid encounter window: %s\n", err)
+				return
+			}
+			err = config.SetEncounterWindow(intValue)
+			if err != nil {
+				fmt.Printf("getUserInput: %s->%s\n", value, err)
+			}
+		case "loglevel":
+			args := strings.Fields(value)
+			if len(args) != 2 {
+				fmt.Println("invalid command: Expected: set loglevel <component|all|console> <debug|info|warn|error>")
+				return
+			}
+			level, err := logger.ParseLevel(args[1])
+			if err != nil {
+				fmt.Printf("getUserInput: %s->%s\n", value, err)
+				return
+			}
+			err = logger.SetLevel(args[0], level)
+			if err != nil {
+				fmt.Printf("getUserInput: %s->%s\n", value, err)
+				return
+			}
+			fmt.Printf("Log level of %s set to: %s\n", args[0], level)
+		case "eqdir":
+			fmt.Println("Setting EverQuest directory to:", value)
+			err = config.SetEQDir(value)
+			if err != nil {
+				fmt.Printf("getUserInput: %s->%s\n", value, err)
+			}
+		case "logsdir":
+			fmt.Println("Setting logs directory to:", value)
+			err = config.SetLogsDir(value)
+			if err != nil {
+				fmt.Printf("getUserInput: %s->%s\n", value, err)
+			}
+		case "raidlogsdir":
+			fmt.Println("Setting raid dumps directory to:", value)
+			err = config.SetRaidLogsDir(value)
+			if err != nil {
+				fmt.Printf("getUserInput: %s->%s\n", value, err)
+			}
+		case "savedraidsdir":
+			fmt.Println("Setting saved raids directory to:", value)
+			err = config.SetSavedRaidsDir(value)
+			if err != nil {
+				fmt.Printf("getUserInput: %s->%s\n", value, err)
+			}
 		case "guildalias":
 			// Get a new alias list from the detected guild roster dump
 			fmt.Println("Importing the guild list from file and generating the alias list...")
@@ -262,10 +463,11 @@ func getUserInput(input, subcommand, value string) {
 			scanner.SetRaidFrequency(intValue)
 		case "raid":
 			if value == "checkin" {
-				err := raid.ActiveRaid.CheckIn()
+				err := raid.Current.CheckIn()
 				if err != nil {
-					fmt.Printf("ActiveRaid.CheckIn(): %s\n", err)
+					fmt.Printf("raid.Current.CheckIn(): %s\n", err)
 				}
+				raid.Current.Publish(events.Event{Kind: events.CheckIn})
 			} else {
 				fmt.Printf("ActiveRaid.CheckIn(): %s\n", "invalid subcommand")
 			}
@@ -292,9 +494,7 @@ func getUserInput(input, subcommand, value string) {
 		case "reset":
 			/*
 				scanner.Stop()
-				core.ClearPlayers()
 				scanner.ResetData()
-				discordwh.ResetData()
 				raid.ResetData()
 				config.ResetData()
 			*/
@@ -308,14 +508,18 @@ func getUserInput(input, subcommand, value string) {
 			serverName := scanner.GetServerName()
 			fmt.Println("Server Name:", serverName)
 		case "loot":
-			if raid.Active {
-				raid.ActiveRaid.PrintLoot()
+			if raid.Current.IsActive() {
+				raid.Current.Raid().PrintLoot()
 			} else {
 				fmt.Println("No active raid")
 			}
 		case "character":
 			characterName := scanner.GetCharacterName()
 			fmt.Println("Character Name:", characterName)
+		case "characters":
+			for index, name := range scanner.GetCharacterNames() {
+				fmt.Printf("%d) %s\n", index+1, name)
+			}
 		case "token":
 			token, err := config.GetBotToken()
 			if err != nil {
@@ -352,6 +556,71 @@ func getUserInput(input, subcommand, value string) {
 				fmt.Printf("GetGuildName(): %s\n", err)
 			}
 			fmt.Println("Guild Name:", guildName)
+		case "lootpolicy":
+			for _, method := range config.GetLootMethods() {
+				policy := config.GetLootPolicy(method)
+				fmt.Printf("%s: announce=%t count=%t\n", method, policy.Announce, policy.Count)
+			}
+		case "raidmode":
+			fmt.Println("Raid mode:", config.GetRaidMode())
+		case "checkinkeyword":
+			keyword, err := config.GetCheckinKeyword()
+			if err != nil {
+				fmt.Printf("GetCheckinKeyword(): %s\n", err)
+			}
+			fmt.Println("Check-in Keyword:", keyword)
+		case "bosses":
+			for index, boss := range config.GetBosses() {
+				fmt.Printf("%d) %s\n", index+1, boss)
+			}
+			fmt.Println("Encounter window:", config.GetEncounterWindow())
+		case "deaths":
+			err := raid.Current.Raid().PrintDeaths()
+			if err != nil {
+				fmt.Printf("ActiveRaid.PrintDeaths(): %s\n", err)
+			}
+		case "parsereport":
+			report, ok := scanner.GetParseReport()
+			if !ok {
+				fmt.Println("No raid dump has been parsed yet")
+				return
+			}
+			fmt.Printf("%s (parsed at %s): %d players, %d lines skipped\n", report.File, report.Time.Format("15:04:05"), report.Parsed, len(report.Skipped))
+			for _, skipped := range report.Skipped {
+				fmt.Printf("Line %d: %q\n\t%s\n", skipped.Line, skipped.Text, skipped.Reason)
+			}
+		case "roster":
+			err := raid.Current.Raid().PrintRosterChanges()
+			if err != nil {
+				fmt.Printf("ActiveRaid.PrintRosterChanges(): %s\n", err)
+			}
+		case "encounters":
+			err := raid.Current.Raid().PrintEncounters()
+			if err != nil {
+				fmt.Printf("ActiveRaid.PrintEncounters(): %s\n", err)
+			}
+		case "loglevel":
+			levels := logger.GetLevels()
+			for _, component := range append(logger.GetComponents(), logger.Console) {
+				fmt.Printf("%s: %s\n", component, levels[component])
+			}
+		case "paths":
+			for _, dir := range []struct {
+				name string
+				get  func() (string, error)
+			}{
+				{"EverQuest", config.GetEQDir},
+				{"Logs", config.GetLogsDir},
+				{"RaidLogs", config.GetRaidLogsDir},
+				{"SavedRaids", config.GetSavedRaidsDir},
+			} {
+				path, err := dir.get()
+				if err != nil {
+					fmt.Printf("%s: %s\n", dir.name, err)
+					continue
+				}
+				fmt.Printf("%s: %s\n", dir.name, path)
+			}
 		case "guildalias":
 			err := alias.ReadGuildMembers()
 			if err != nil {
@@ -364,9 +633,9 @@ func getUserInput(input, subcommand, value string) {
 		case "raid":
 			switch value {
 			case "":
-				err := raid.ActiveRaid.PrintParticipation()
+				err := raid.Current.PrintParticipation()
 				if err != nil {
-					fmt.Printf("ActiveRaid.PrintParticipation(): %s\n", err)
+					fmt.Printf("raid.Current.PrintParticipation(): %s\n", err)
 				}
 			default:
 				fmt.Printf("raid: invalid subcommand --> %s\n", subcommand)
@@ -384,24 +653,23 @@ func getUserInput(input, subcommand, value string) {
 		case "displaylist":
 			switch value {
 			case "":
-				err := raid.PrintDisplayList()
+				err := raid.Current.PrintDisplayList()
 				if err != nil {
-					fmt.Printf("raid.PrintDisplayList(): %s\n", err)
+					fmt.Printf("raid.Current.PrintDisplayList(): %s\n", err)
 				}
 			default:
 				fmt.Printf("displaylist: invalid subcommand --> %s\n", subcommand)
 			}
 		case "lastraid":
-			err := raid.ActiveRaid.Load(value)
+			err := raid.Current.Load(value)
 			if err != nil {
-				fmt.Printf("ActiveRaid.Load(): %s\n", err)
+				fmt.Printf("raid.Current.Load(): %s\n", err)
 			}
 		case "commands":
 			printCommands()
 		case "quit":
 			fmt.Println("[Status] Exiting...")
-			config.SaveConfig()
-			os.Exit(0)
+			exit()
 		default:
 			fmt.Printf("invalid command(%s)\n", subcommand)
 		}
@@ -416,6 +684,32 @@ func getUserInput(input, subcommand, value string) {
 		if err != nil {
 			fmt.Printf("AddAlias(): %s\n", err)
 		}
+	case "roll":
+		handleRollCommand(subcommand, value)
+	case "raid":
+		handleRaidCommand(subcommand, value)
+	case "raids":
+		handleRaidsCommand(subcommand, value)
+	case "replay":
+		timeRange := strings.Fields(value)
+		if subcommand == "" || len(timeRange) != 2 {
+			fmt.Println("invalid command: Expected: replay <eqlog> <from> <to> (times as 2006-01-02T15:04)")
+			return
+		}
+		from, err := parseCommandTime(timeRange[0])
+		if err != nil {
+			fmt.Printf("replay: %s\n", err)
+			return
+		}
+		to, err := parseCommandTime(timeRange[1])
+		if err != nil {
+			fmt.Printf("replay: %s\n", err)
+			return
+		}
+		err = scanner.Replay(subcommand, from, to)
+		if err != nil {
+			fmt.Printf("scanner.Replay(): %s\n", err)
+		}
 	case "ping":
 		fmt.Println("Pong")
 	default:
@@ -426,11 +720,253 @@ func getUserInput(input, subcommand, value string) {
 	}
 }
 
-func close() {
-	fmt.Println("Cleaning up before exit...")
-	if raid.Active {
-		raid.Active = false
+// Parses a date/time command argument, ie: 2022-02-16T20:00 or 2022-02-16
+func parseCommandTime(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		parsed, err := time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %s (expected 2006-01-02T15:04)", value)
+}
 
+// Handles the raid lifecycle commands: start [name], pause, resume, end, recover [file] and status
+func handleRaidCommand(subcommand, value string) {
+	var err error
+	switch subcommand {
+	case "start":
+		err = scanner.StartRaid(value)
+		if err == nil {
+			fmt.Println("Raid started:", raid.Current.Raid().Name)
+		}
+	case "pause":
+		err = scanner.PauseRaid()
+		if err == nil {
+			fmt.Println("Raid paused, nothing is recorded until it is resumed")
+		}
+	case "resume":
+		err = scanner.ResumeRaid()
+		if err == nil {
+			fmt.Println("Raid resumed")
+		}
+	case "end":
+		err = scanner.EndRaid()
+		if err == nil {
+			fmt.Println("Raid ended:", raid.Current.Raid().Name)
+		}
+	case "recover":
+		fileName := value
+		if fileName == "" {
+			fileName, err = raid.FindUnfinishedRaid()
+			if err == nil && fileName == "" {
+				fmt.Println("No unfinished raid found")
+				return
+			}
+		}
+		if err == nil {
+			err = scanner.RecoverRaid(fileName)
+		}
+		if err == nil {
+			fmt.Println("Raid resumed:", raid.Current.Raid().Name)
+		}
+	case "name", "describe", "tag", "untag":
+		err = editRaid(subcommand, value)
+		if err == nil {
+			fmt.Println("Raid updated")
+		}
+	case "status":
+		state := raid.Current.State()
+		if state == raid.StateIdle {
+			fmt.Printf("No raid is open (%s mode)\n", config.GetRaidMode())
+		} else {
+			activeRaid := raid.Current.Raid()
+			fmt.Printf("%s is %s (%s mode)\n", activeRaid.Title(), state, config.GetRaidMode())
+			if activeRaid.Description != "" {
+				fmt.Println(activeRaid.Description)
+			}
+		}
+	default:
+		fmt.Println("invalid command: Expected: raid start [name], raid pause, raid resume, raid end, raid recover [file], raid name|describe|tag|untag [file] <value> or raid status")
+	}
+	if err != nil {
+		fmt.Printf("raid %s: %s\n", subcommand, err)
+	}
+}
+
+// Handles the raid history commands: list [from] [to] [zone=<zone>] [tag=<tag>], show <name>, player <name> and attendance [post]
+func handleRaidsCommand(subcommand, value string) {
+	var err error
+	switch {
+	case subcommand == "list":
+		var filter raid.Filter
+		filter, err = parseRaidFilter(value)
+		if err == nil {
+			err = raid.AllRaids.PrintList(filter)
+		}
+	case subcommand == "show" && value != "":
+		var found raid.Raid
+		found, err = raid.AllRaids.Find(value)
+		if err == nil {
+			found.PrintDetails()
+		}
+	case subcommand == "player" && value != "":
+		err = raid.AllRaids.PrintPlayer(value)
+	case subcommand == "attendance" && (value == "" || value == "post"):
+		lines := raid.FormatAttendance(raid.AllRaids.HandleAttendance(time.Now()))
+		if len(lines) == 1 {
+			err = fmt.Errorf("no check-ins found")
+			break
+		}
+		fmt.Println(strings.Join(lines, "\n"))
+		if value == "post" {
+			err = discord.SendEmbedTable("Raid Attendance", lines, 2)
+		}
+	default:
+		fmt.Println("invalid command: Expected: raids list [from] [to] [zone=<zone>] [tag=<tag>], raids show <name>, raids player <name> or raids attendance [post]")
 	}
+	if err != nil {
+		fmt.Printf("raids %s: %s\n", subcommand, err)
+	}
+}
+
+// Parses the raids list arguments: up to two dates followed by zone and tag filters, ie: 2022-02-01 2022-02-28 zone=plane of fear tag=velious.
+// A date without a time includes the whole day
+func parseRaidFilter(value string) (raid.Filter, error) {
+	filter := raid.Filter{}
+	dates := []time.Time{}
+	var current *string // Filter the words following zone= or tag= are appended to
+	for _, word := range strings.Fields(value) {
+		switch {
+		case strings.HasPrefix(word, "zone="):
+			filter.Zone = strings.TrimPrefix(word, "zone=")
+			current = &filter.Zone
+		case strings.HasPrefix(word, "tag="):
+			filter.Tag = strings.TrimPrefix(word, "tag=")
+			current = &filter.Tag
+		case current != nil:
+			*current += " " + word
+		case len(dates) == 2:
+			return filter, fmt.Errorf("unexpected argument: %s", word)
+		default:
+			parsed, err := parseCommandTime(word)
+			if err != nil {
+				return filter, err
+			}
+			if len(dates) == 1 && len(word) == len("2006-01-02") {
+				parsed = parsed.AddDate(0, 0, 1)
+			}
+			dates = append(dates, parsed)
+		}
+	}
+	if len(dates) > 0 {
+		filter.From = dates[0]
+	}
+	if len(dates) > 1 {
+		filter.To = dates[1]
+	}
+	return filter, nil
+}
+
+// Handles the raid edit commands: name <name>, describe <text>, tag <tags> and untag <tags>, with tags separated by commas.
+// The open raid is edited, unless the value starts with the file name of a saved raid
+func editRaid(subcommand, value string) error {
+	fileName := ""
+	if words := strings.Fields(value); len(words) > 0 && strings.HasSuffix(words[0], ".json") {
+		fileName = words[0]
+		value = strings.TrimSpace(strings.TrimPrefix(value, fileName))
+	}
+	var change func(*raid.Raid)
+	switch subcommand {
+	case "name":
+		if value == "" {
+			return fmt.Errorf("no name provided")
+		}
+		change = func(r *raid.Raid) { r.Name = value }
+	case "describe":
+		change = func(r *raid.Raid) { r.Description = value }
+	case "tag", "untag":
+		if value == "" {
+			return fmt.Errorf("no tags provided")
+		}
+		tags := strings.Split(value, ",")
+		change = func(r *raid.Raid) { r.AddTags(tags...) }
+		if subcommand == "untag" {
+			change = func(r *raid.Raid) { r.RemoveTags(tags...) }
+		}
+	}
+	if fileName != "" {
+		return raid.EditSavedRaid(fileName, change)
+	}
+	err := raid.Current.Edit(change)
+	if err != nil {
+		return err
+	}
+	raid.Current.Publish(events.Event{Kind: events.RaidUpdated})
+	return nil
+}
+
+// Handles the roll commands: open <range> <item>, close <range> and list
+func handleRollCommand(subcommand, value string) {
+	switch subcommand {
+	case "open":
+		rangeArg, item := value, ""
+		if index := strings.Index(value, " "); index >= 0 {
+			rangeArg, item = value[:index], strings.TrimSpace(value[index+1:])
+		}
+		low, high, err := roll.ParseRange(rangeArg)
+		if err != nil {
+			fmt.Printf("roll open: %s\n", err)
+			return
+		}
+		err = roll.Open(item, low, high, core.Now().Truncate(time.Second))
+		if err != nil {
+			fmt.Printf("roll open: %s\n", err)
+			return
+		}
+		rollMessage := fmt.Sprintf("Roll open for %s: /random %d %d", item, low, high)
+		fmt.Println(rollMessage)
+		discord.SendMessage(rollMessage, 1)
+	case "close":
+		low, high, err := roll.ParseRange(value)
+		if err != nil {
+			fmt.Printf("roll close: %s\n", err)
+			return
+		}
+		session, err := roll.Close(low, high)
+		if err != nil {
+			fmt.Printf("roll close: %s\n", err)
+			return
+		}
+		results := session.Results()
+		fmt.Println(results)
+		err = discord.SendEmbedMessage("Roll Results: "+session.Item, results, 1)
+		if err != nil {
+			fmt.Printf("roll close: %s\n", err)
+		}
+	case "list":
+		sessions := roll.List()
+		if len(sessions) == 0 {
+			fmt.Println("No rolls are open")
+		}
+		for _, session := range sessions {
+			fmt.Printf("[%s] %s\n", roll.Key(session.Low, session.High), session.Results())
+		}
+	default:
+		fmt.Println("invalid command: Expected: roll open <range> <item>, roll close <range> or roll list")
+	}
+}
+
+// Runs the shutdown steps deferred by main before exiting, os.Exit skips deferred calls
+func exit() {
+	close()
+	mongodb.DisconnectALL()
+	os.Exit(0)
+}
+
+func close() {
+	fmt.Println("Cleaning up before exit...")
+	events.Wait() // Let pending raid saves and announcements finish
 	config.SaveConfig()
+	logger.Close()
 }
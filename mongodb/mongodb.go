@@ -6,6 +6,7 @@ import (
 
 	"github.com/Valorith/EQRaidAssist/config"
 	"github.com/Valorith/EQRaidAssist/core"
+	"github.com/Valorith/EQRaidAssist/logger"
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -48,17 +49,17 @@ func Init() {
 func DisconnectALL() {
 	err := AliasDB.Disconnect()
 	if err != nil {
-		fmt.Println("Error disconnecting from alias database:", err)
+		logger.Warnf(logger.MongoDB, "Error disconnecting from alias database: %v", err)
 	}
 	err = RaidsDB.Disconnect()
 	if err != nil {
-		fmt.Println("Error disconnecting from raids database:", err)
+		logger.Warnf(logger.MongoDB, "Error disconnecting from raids database: %v", err)
 	}
 }
 
 func (db *database) Connect() error {
 	// Set client options
-	fmt.Println("Configuring database connection...")
+	logger.Infof(logger.MongoDB, "Configuring database connection...")
 
 	// Load .env
 	viper.SetConfigFile(".env")
@@ -108,20 +109,20 @@ func (db *database) Connect() error {
 	db.Collection = db.Client.Database(db.DatabaseName).Collection(db.CollectionName)
 
 	// Connect to MongoDB
-	fmt.Printf("Attempting to connect to database(%s\\%s\\%s)...\n", db.ClusterName, db.DatabaseName, db.CollectionName)
+	logger.Infof(logger.MongoDB, "Attempting to connect to database(%s\\%s\\%s)...", db.ClusterName, db.DatabaseName, db.CollectionName)
 	err = db.Client.Connect(db.Context)
 	if err != nil {
 		return fmt.Errorf("Connect(): error connecting to mongo: %v", err)
 	}
-	fmt.Println("Connected to database.")
+	logger.Infof(logger.MongoDB, "Connected to database.")
 
 	// Test connection
-	fmt.Println("Testing database connetion...")
+	logger.Debugf(logger.MongoDB, "Testing database connetion...")
 	err = db.Client.Ping(db.Context, nil)
 	if err != nil {
 		return fmt.Errorf("Connect(): error pinging mongo: %v", err)
 	}
-	fmt.Println("Database test succesful.")
+	logger.Debugf(logger.MongoDB, "Database test succesful.")
 	db.Connected = true
 	return nil
 }
@@ -139,12 +140,25 @@ func (db *database) Insert(data interface{}) error {
 		if err != nil {
 			return fmt.Errorf("Insert(): error inserting data: %v", err)
 		}
-		fmt.Printf("Inserted data into database(%s\\%s\\%s)...Result ID: %s...\n", db.ClusterName, db.DatabaseName, db.CollectionName, result.InsertedID)
+		logger.Debugf(logger.MongoDB, "Inserted data into database(%s\\%s\\%s)...Result ID: %s...", db.ClusterName, db.DatabaseName, db.CollectionName, result.InsertedID)
 		return nil
 	}
 	return fmt.Errorf("Insert(): database not connected")
 }
 
+// Replaces the first document matching the filter with a struct of data
+func (db *database) Replace(filter, data interface{}) error {
+	if db.Connected {
+		result, err := db.Collection.ReplaceOne(db.Context, filter, data)
+		if err != nil {
+			return fmt.Errorf("Replace(): error replacing data: %v", err)
+		}
+		logger.Debugf(logger.MongoDB, "Replaced data in database(%s\\%s\\%s)...Matched: %d...", db.ClusterName, db.DatabaseName, db.CollectionName, result.MatchedCount)
+		return nil
+	}
+	return fmt.Errorf("Replace(): database not connected")
+}
+
 func getEnvVarString(key string) string {
 	value := viper.GetString(key)
 	return value
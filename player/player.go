@@ -4,21 +4,43 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Represents an EverQuest player
 type Player struct {
-	Name  string     `json:"name"`     // Name of the player
-	Level int        `json:"level"`    // Level of the player
-	Class string     `json:"class"`    // Class of the player
-	Group int        `json:"group"`    // Raid Group number
-	Loot  []LootItem `json:"lootitem"` // Loot attributed to the player
+	Name         string     `json:"name"`         // Name of the player
+	Level        int        `json:"level"`        // Level of the player
+	Class        string     `json:"class"`        // Class of the player
+	Group        int        `json:"group"`        // Raid Group number
+	GroupLeader  bool       `json:"groupleader"`  // Leads their raid group
+	RaidLeader   bool       `json:"raidleader"`   // Leads the raid
+	MasterLooter bool       `json:"masterlooter"` // Distributes the raid's loot
+	Flags        []string   `json:"flags"`        // Any further markers from the raid dump
+	Loot         []LootItem `json:"lootitem"`     // Loot attributed to the player
 }
 
+// Columns of a raid dump (/outputfile raid) line, separated by tabs
+const (
+	columnGroup        = iota
+	columnName         // Character name
+	columnLevel        // Character level
+	columnClass        // Character class
+	columnRank         // "Group Leader" or "Raid Leader"
+	columnMasterLooter // "Yes" when the character is the master looter
+	columnFlags        // Remaining columns hold any further markers
+)
+
 type LootItem struct {
-	Name        string `json:"name"`
-	Count       int    `json:"count"`
-	Description string `json:"description"`
+	Name          string    `json:"name"`
+	Count         int       `json:"count"`
+	Description   string    `json:"description"`
+	Method        string    `json:"method"`        // Distribution method (council, random, assigned, masterloot, corpse)
+	Corpse        string    `json:"corpse"`        // Name of the corpse the item was looted from, if any
+	Encounter     string    `json:"encounter"`     // Boss encounter the item was linked to, if any
+	Time          time.Time `json:"time"`          // Time the item was awarded
+	Source        string    `json:"source"`        // Character whose log reported the award
+	CountsAgainst bool      `json:"countsagainst"` // Whether the award counts against the player
 }
 
 // NewFromLine takes a line argument and creates a new player
@@ -26,22 +48,43 @@ func NewFromLine(line string) (*Player, error) {
 	var err error
 	p := &Player{}
 
-	formattedLine := strings.Replace(line, "\t", ",", -1)
-	in := formattedLine[0:strings.Index(formattedLine, ",")]
-	p.Group, err = strconv.Atoi(in)
+	columns := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
+	for index := range columns {
+		columns[index] = strings.TrimSpace(columns[index])
+	}
+	if len(columns) <= columnClass {
+		return nil, fmt.Errorf("NewFromLine(): expected at least %d columns, found %d", columnClass+1, len(columns))
+	}
+	p.Group, err = strconv.Atoi(columns[columnGroup])
 	if err != nil {
-		return nil, fmt.Errorf("atoi groupNumber %s: %w", in, err)
+		return nil, fmt.Errorf("atoi groupNumber %s: %w", columns[columnGroup], err)
+	}
+	p.Name = columns[columnName]
+	if p.Name == "" {
+		return nil, fmt.Errorf("NewFromLine(): missing character name")
 	}
-	formattedLine = formattedLine[strings.Index(formattedLine, ",")+1:]
-	p.Name = formattedLine[0:strings.Index(formattedLine, ",")]
-	formattedLine = formattedLine[strings.Index(formattedLine, ",")+1:]
-	in = formattedLine[0:strings.Index(formattedLine, ",")]
-	p.Level, err = strconv.Atoi(in)
+	p.Level, err = strconv.Atoi(columns[columnLevel])
 	if err != nil {
-		return nil, fmt.Errorf("atoi charLevel %s: %w", in, err)
+		return nil, fmt.Errorf("atoi charLevel %s: %w", columns[columnLevel], err)
+	}
+	p.Class = columns[columnClass]
+	if len(columns) > columnRank {
+		switch strings.ToLower(columns[columnRank]) {
+		case "group leader":
+			p.GroupLeader = true
+		case "raid leader":
+			p.RaidLeader = true
+		}
+	}
+	if len(columns) > columnMasterLooter {
+		marker := strings.ToLower(columns[columnMasterLooter])
+		p.MasterLooter = marker == "yes" || marker == "master looter"
+	}
+	for index := columnFlags; index < len(columns); index++ {
+		if columns[index] != "" {
+			p.Flags = append(p.Flags, columns[index])
+		}
 	}
-	formattedLine = formattedLine[strings.Index(formattedLine, ",")+1:]
-	p.Class = formattedLine[0:strings.Index(formattedLine, ",")]
 	return p, nil
 }
 
@@ -51,9 +94,21 @@ func (p *Player) String() string {
 	out = fmt.Sprintf("%s\nChar Level: %d", out, p.Level)
 	out = fmt.Sprintf("%s\nChar Class: %s", out, p.Class)
 	out = fmt.Sprintf("%s\nGroup Number: %d", out, p.Group)
+	if p.RaidLeader {
+		out = fmt.Sprintf("%s\nRaid Leader", out)
+	}
+	if p.GroupLeader {
+		out = fmt.Sprintf("%s\nGroup Leader", out)
+	}
+	if p.MasterLooter {
+		out = fmt.Sprintf("%s\nMaster Looter", out)
+	}
+	if len(p.Flags) > 0 {
+		out = fmt.Sprintf("%s\nFlags: %s", out, strings.Join(p.Flags, ", "))
+	}
 	out = fmt.Sprintf("%s\nLoot: ", out)
 	for _, lootItem := range p.Loot {
-		out = fmt.Sprintf("%s\t %s\n", out, lootItem.Name)
+		out = fmt.Sprintf("%s\t %s (%s)\n", out, lootItem.Name, lootItem.Method)
 	}
 	out = fmt.Sprintf("%s\n------------------\n", out)
 	return out
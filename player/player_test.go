@@ -0,0 +1,41 @@
+package player
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewFromLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected Player
+	}{
+		{
+			line:     "1\tValgor\t60\tWarrior\tGroup Leader\t\t\r\n",
+			expected: Player{Name: "Valgor", Level: 60, Class: "Warrior", Group: 1, GroupLeader: true},
+		},
+		{
+			line:     "2\tRaidlead\t60\tCleric\tRaid Leader\tYes\tMain Assist",
+			expected: Player{Name: "Raidlead", Level: 60, Class: "Cleric", Group: 2, RaidLeader: true, MasterLooter: true, Flags: []string{"Main Assist"}},
+		},
+		{
+			line:     "0\tLoner\t55\tRogue",
+			expected: Player{Name: "Loner", Level: 55, Class: "Rogue"},
+		},
+	}
+	for _, test := range tests {
+		p, err := NewFromLine(test.line)
+		if err != nil {
+			t.Fatalf("NewFromLine(%q): %s", test.line, err)
+		}
+		if !reflect.DeepEqual(*p, test.expected) {
+			t.Errorf("NewFromLine(%q) = %+v, expected %+v", test.line, *p, test.expected)
+		}
+	}
+
+	for _, line := range []string{"", "1\tValgor\t60", "x\tValgor\t60\tWarrior"} {
+		if _, err := NewFromLine(line); err == nil {
+			t.Errorf("NewFromLine(%q): expected an error", line)
+		}
+	}
+}
@@ -2,16 +2,22 @@ package raid
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Valorith/EQRaidAssist/alias"
+	"github.com/Valorith/EQRaidAssist/config"
 	"github.com/Valorith/EQRaidAssist/core"
+	"github.com/Valorith/EQRaidAssist/events"
+	"github.com/Valorith/EQRaidAssist/logger"
 	"github.com/Valorith/EQRaidAssist/mongodb"
 	"github.com/Valorith/EQRaidAssist/player"
 	"go.mongodb.org/mongo-driver/bson"
@@ -19,18 +25,402 @@ import (
 )
 
 var (
-	mu          sync.Mutex
-	Active      bool
-	ActiveRaid  Raid
-	AllRaids    RaidCollection = RaidCollection{}
-	DisplayList map[string]int = map[string]int{}
+	AllRaids RaidCollection = RaidCollection{}
+	Current  *Session       = NewSession() // Session shared by the scanner and the command handlers
 )
 
 func ResetData() {
-	mu.Lock()
-	defer mu.Unlock()
-	Active = false
-	ActiveRaid = Raid{}
+	Current.Reset()
+}
+
+// Session holds the state shared by the scanner and the command handlers: the players cache,
+// the active raid, its display list and the state of the scanner.
+// All access goes through its methods, which are safe to call from multiple goroutines
+type Session struct {
+	mu             sync.Mutex
+	players        []*player.Player     // Players detected within the latest raid dump
+	state          State                // Lifecycle state of the raid
+	raid           Raid                 // Active raid, or the last raid started or loaded
+	displayList    map[string]int       // Highest check-in count of each handle [handle]checkIns
+	chatCheckins   map[string]time.Time // Characters that checked in through chat since the last check-in, and when
+	scannerStarted bool                 // Flags the scanner as active or inactive
+	loadedRaidFile string               // Directory of the raid dump file loaded last
+}
+
+// Returns an empty session
+func NewSession() *Session {
+	return &Session{displayList: map[string]int{}, chatCheckins: map[string]time.Time{}}
+}
+
+// Clears the players cache and the active raid
+func (s *Session) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.players = nil
+	s.state = StateIdle
+	s.raid = Raid{}
+	s.displayList = map[string]int{}
+	s.chatCheckins = map[string]time.Time{}
+}
+
+// Returns true if a raid is being recorded
+func (s *Session) IsActive() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state == StateActive
+}
+
+// Returns the lifecycle state of the raid
+func (s *Session) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// State is the lifecycle state of a session's raid
+type State int
+
+const (
+	StateIdle   State = iota // No raid is open, raid dumps only update the players cache
+	StateActive              // The raid records check-ins, loot, encounters and deaths
+	StatePaused              // The raid is open, but nothing is recorded until it is resumed
+)
+
+func (state State) String() string {
+	switch state {
+	case StateIdle:
+		return "idle"
+	case StateActive:
+		return "active"
+	case StatePaused:
+		return "paused"
+	default:
+		return "unknown"
+	}
+}
+
+// Lifecycle actions, along with the states they are allowed from and the state they lead to
+var transitions = map[string]struct {
+	from []State
+	to   State
+}{
+	"Start":       {from: []State{StateIdle}, to: StateActive},
+	"Pause":       {from: []State{StateActive}, to: StatePaused},
+	"Resume":      {from: []State{StatePaused}, to: StateActive},
+	"Stop":        {from: []State{StateActive, StatePaused}, to: StateIdle},
+	"StopOffline": {from: []State{StateActive, StatePaused}, to: StateIdle},
+}
+
+// Applies the lifecycle action, if the current state allows it
+func (s *Session) transition(action string) error {
+	allowed := transitions[action]
+	for _, from := range allowed.from {
+		if s.state == from {
+			s.state = allowed.to
+			return nil
+		}
+	}
+	return fmt.Errorf("%s(): the raid is %s", action, s.state)
+}
+
+// Returns a copy of the active raid
+func (s *Session) Raid() Raid {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.raid.copy()
+}
+
+// Returns a copy of the display list
+func (s *Session) DisplayList() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyCounts(s.displayList)
+}
+
+// Returns a copy of the players cache
+func (s *Session) Players() []*player.Player {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyPlayers(s.players)
+}
+
+// Adds a copy of the player to the players cache
+func (s *Session) AddPlayer(p *player.Player) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isCachedPlayer(p.Name) {
+		return fmt.Errorf("player %s is already cached", p.Name)
+	}
+	s.players = append(s.players, copyPlayers([]*player.Player{p})...)
+	return nil
+}
+
+func (s *Session) ClearPlayers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.players = nil
+	logger.Debugf(logger.Raid, "Cached players cleared...")
+}
+
+// Check if the provided characterName is in the players cache
+func (s *Session) IsCachedPlayer(characterName string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isCachedPlayer(characterName)
+}
+
+func (s *Session) isCachedPlayer(characterName string) bool {
+	for _, p := range s.players {
+		if p.Name == characterName {
+			return true
+		}
+	}
+	return false
+}
+
+// Attributes the loot item to a member of the raid, or to a cached player while no raid is open.
+// An award already recorded (ie: log lines read again after recovering from a crash) is ignored.
+// Returns true if the item was recorded
+func (s *Session) AddLoot(characterName string, item player.LootItem) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, players := range [][]*player.Player{s.raid.Players, s.players} {
+		for _, p := range players {
+			if p.Name != characterName {
+				continue
+			}
+			for _, recorded := range p.Loot {
+				if recorded.Name == item.Name && recorded.Time.Equal(item.Time) && recorded.Source == item.Source && recorded.Method == item.Method {
+					return false, nil
+				}
+			}
+			err := p.AddLoot(item)
+			if err != nil {
+				return false, fmt.Errorf("AddLoot(): %w", err)
+			}
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("AddLoot(): %s is not in the raid or the players cache", characterName)
+}
+
+// Records an item no player could be credited with, ie: one taken by an unknown master looter.
+// Returns false if the item was already recorded
+func (s *Session) AddUnassigned(item player.LootItem) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, recorded := range s.raid.Unassigned {
+		if recorded.Name == item.Name && recorded.Time.Equal(item.Time) && recorded.Source == item.Source {
+			return false
+		}
+	}
+	s.raid.Unassigned = append(s.raid.Unassigned, item)
+	return true
+}
+
+// Returns the master looter of the latest raid dump, or an empty string if it is unknown
+func (s *Session) MasterLooter() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.raid.MasterLooter
+}
+
+// Resumes an unfinished raid saved to file, ie: after a crash. The members present in its
+// latest raid dump become the players cache, and the raid is recorded again unless it was paused
+func (s *Session) Recover(fileName string) error {
+	savedRaid, err := LoadRaid(fileName)
+	if err != nil {
+		return fmt.Errorf("Recover(): %w", err)
+	}
+	if !savedRaid.Active {
+		return fmt.Errorf("Recover(): %s has already ended", fileName)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state != StateIdle {
+		return fmt.Errorf("Recover(): the raid is %s", s.state)
+	}
+	if savedRaid.Checkins == nil {
+		savedRaid.Checkins = make(map[string]int)
+	}
+	if savedRaid.ChatCheckins == nil {
+		savedRaid.ChatCheckins = make(map[string]int)
+	}
+	s.raid = savedRaid
+	s.players = nil
+	for _, p := range s.raid.Players {
+		intervals := s.raid.Presence[p.Name]
+		if len(s.raid.Presence) == 0 || (len(intervals) > 0 && intervals[len(intervals)-1].End.Equal(s.raid.LastDump)) {
+			s.players = append(s.players, p)
+		}
+	}
+	s.state = StateActive
+	if s.raid.Paused {
+		s.state = StatePaused
+	}
+	s.updateDisplayList()
+	return nil
+}
+
+// Returns the newest saved raid file that was never ended (ie: because of a crash),
+// or an empty string if there is none
+func FindUnfinishedRaid() (string, error) {
+	savedRaidFiles, err := getSavedRaidFiles()
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("FindUnfinishedRaid(): %w", err)
+	}
+	savedRaidsFolder, err := config.GetSavedRaidsDir()
+	if err != nil {
+		return "", fmt.Errorf("FindUnfinishedRaid(): %w", err)
+	}
+	newest := ""
+	var newestModified time.Time
+	for _, fileName := range savedRaidFiles {
+		savedRaid, err := LoadRaid(fileName)
+		if err != nil {
+			logger.Warnf(logger.Raid, "FindUnfinishedRaid(): %s", err)
+			continue
+		}
+		if !savedRaid.Active {
+			continue
+		}
+		fileStat, err := os.Stat(filepath.Join(savedRaidsFolder, fileName))
+		if err != nil {
+			return "", fmt.Errorf("FindUnfinishedRaid(): os.Stat: %w", err)
+		}
+		if newest == "" || fileStat.ModTime().After(newestModified) {
+			newest = fileName
+			newestModified = fileStat.ModTime()
+		}
+	}
+	return newest, nil
+}
+
+// Publishes the event along with a snapshot of the active raid.
+// Publishing under the session lock keeps snapshots in the order they were taken
+func (s *Session) Publish(event events.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event.Raid = s.raid.Name
+	event.Snapshot = s.raid.copy()
+	events.Publish(event)
+}
+
+// Subscribes the raid file and database writers to the event bus.
+// They never drop events, so the final snapshot of a stopped raid is always saved
+func Subscribe() {
+	events.SubscribeBlocking("raid file", events.DefaultBuffer, saveSnapshot)
+	events.SubscribeBlocking("raid database", events.DefaultBuffer, addSnapshotToDB, events.RaidStopped)
+}
+
+// Saves the raid snapshot of the event to file
+func saveSnapshot(event events.Event) {
+	snapshot, ok := event.Snapshot.(Raid)
+	if !ok || snapshot.FileName == "" {
+		return
+	}
+	err := snapshot.SaveToFile()
+	if err != nil {
+		logger.Errorf(logger.Raid, "saveSnapshot: %s", err)
+	}
+}
+
+// Adds the stopped raid to the database, replayed raids are only saved to file
+func addSnapshotToDB(event events.Event) {
+	snapshot, ok := event.Snapshot.(Raid)
+	if !ok || event.Replay {
+		return
+	}
+	err := snapshot.AddToDB()
+	if err != nil {
+		logger.Errorf(logger.Raid, "addSnapshotToDB: %s", err)
+	}
+}
+
+// Returns true if the scanner is running
+func (s *Session) ScannerStarted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scannerStarted
+}
+
+func (s *Session) SetScannerStarted(started bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scannerStarted = started
+}
+
+// Returns the directory of the raid dump file loaded last
+func (s *Session) LoadedRaidFile() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadedRaidFile
+}
+
+func (s *Session) SetLoadedRaidFile(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadedRaidFile = path
+}
+
+// Returns a deep copy of the raid, which can be read while the session keeps changing
+func (raid Raid) copy() Raid {
+	out := raid
+	out.Checkins = copyCounts(raid.Checkins)
+	out.ChatCheckins = copyCounts(raid.ChatCheckins)
+	out.Players = copyPlayers(raid.Players)
+	out.Encounters = nil
+	for _, encounter := range raid.Encounters {
+		encounter.Roster = append([]string{}, encounter.Roster...)
+		encounter.Loot = append([]EncounterLoot{}, encounter.Loot...)
+		out.Encounters = append(out.Encounters, encounter)
+	}
+	out.Tags = append([]string(nil), raid.Tags...)
+	out.Unassigned = append([]player.LootItem(nil), raid.Unassigned...)
+	out.Zones = append([]ZoneChange(nil), raid.Zones...)
+	out.Deaths = append([]Death(nil), raid.Deaths...)
+	out.RosterChanges = append([]RosterChange(nil), raid.RosterChanges...)
+	if raid.Presence != nil {
+		out.Presence = make(map[string][]PresenceInterval)
+		for name, intervals := range raid.Presence {
+			out.Presence[name] = append([]PresenceInterval(nil), intervals...)
+		}
+	}
+	if raid.Attendance != nil {
+		out.Attendance = make(map[string]Attendance)
+		for name, attendance := range raid.Attendance {
+			out.Attendance[name] = attendance
+		}
+	}
+	return out
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	if counts == nil {
+		return nil
+	}
+	out := make(map[string]int)
+	for name, count := range counts {
+		out[name] = count
+	}
+	return out
+}
+
+func copyPlayers(players []*player.Player) []*player.Player {
+	if players == nil {
+		return nil
+	}
+	out := []*player.Player{}
+	for _, p := range players {
+		copied := *p
+		copied.Flags = append([]string(nil), p.Flags...)
+		copied.Loot = append([]player.LootItem(nil), p.Loot...)
+		out = append(out, &copied)
+	}
+	return out
 }
 
 type RaidCollection struct {
@@ -38,56 +428,265 @@ type RaidCollection struct {
 }
 
 type Raid struct {
-	Name        string           `json:"name"`        // Name of the raid
-	StartYear   int              `json:"startyear"`   // Start year of the raid
-	StartMonth  int              `json:"startmonth"`  // Start month of the raid
-	StartDay    int              `json:"startday"`    // Start day of the raid
-	StartHour   int              `json:"starthour"`   // Start day of the raid
-	StartMinute int              `json:"startminute"` // Start day of the raid
-	StartSecond int              `json:"startsecond"` // Start day of the raid
-	Description string           `json:"description"` // Raid description
-	Checkins    map[string]int   `json:"checkins"`    // Map of raid check-ins for each respective member [player_anme]checkIns
-	Players     []*player.Player `json:"players"`     // List of players in the raid
-	FileName    string           `json:"filename"`
-	Active      bool             `json:"active"` // Indicates whether the raid is active or not
+	Name          string                        `json:"name"`        // Name of the raid
+	StartYear     int                           `json:"startyear"`   // Start year of the raid
+	StartMonth    int                           `json:"startmonth"`  // Start month of the raid
+	StartDay      int                           `json:"startday"`    // Start day of the raid
+	StartHour     int                           `json:"starthour"`   // Start day of the raid
+	StartMinute   int                           `json:"startminute"` // Start day of the raid
+	StartSecond   int                           `json:"startsecond"` // Start day of the raid
+	Description   string                        `json:"description"` // Raid description
+	Tags          []string                      `json:"tags"`        // Tags used to filter raid history, ie: zone, expansion or "alt night"
+	Checkins      map[string]int                `json:"checkins"`    // Map of raid check-ins for each respective member [player_anme]checkIns
+	Players       []*player.Player              `json:"players"`     // List of players in the raid
+	FileName      string                        `json:"filename"`
+	Active        bool                          `json:"active"`        // Indicates whether the raid is active or not
+	Paused        bool                          `json:"paused"`        // Indicates whether recording the raid is paused
+	Encounters    []Encounter                   `json:"encounters"`    // Boss kills recorded during the raid
+	Zones         []ZoneChange                  `json:"zones"`         // Zones entered during the raid, in order
+	ChatCheckins  map[string]int                `json:"chatcheckins"`  // Check-ins credited through chat rather than a raid dump [player_name]checkIns
+	Deaths        []Death                       `json:"deaths"`        // Player deaths recorded during the raid
+	RaidLeader    string                        `json:"raidleader"`    // Raid leader in the latest raid dump
+	MasterLooter  string                        `json:"masterlooter"`  // Master looter in the latest raid dump
+	Unassigned    []player.LootItem             `json:"unassigned"`    // Items taken by the master looter while the master looter was unknown
+	RosterChanges []RosterChange                `json:"rosterchanges"` // Differences between consecutive raid dumps
+	Presence      map[string][]PresenceInterval `json:"presence"`      // Time each member was seen in consecutive raid dumps
+	Attendance    map[string]Attendance         `json:"attendance"`    // Time weighted attendance of each member
+	FirstDump     time.Time                     `json:"firstdump"`     // Time of the first raid dump
+	LastDump      time.Time                     `json:"lastdump"`      // Time of the latest raid dump
+}
+
+// Represents a stretch of consecutive raid dumps a member was present in
+type PresenceInterval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Represents the time a member was present for during the raid
+type Attendance struct {
+	Minutes int     `json:"minutes"` // Minutes present
+	Percent float64 `json:"percent"` // Percent of the raid duration present
+}
+
+// Represents a difference between two consecutive raid dumps
+type RosterChange struct {
+	Time   time.Time `json:"time"`   // Time of the raid dump the change was detected in
+	Player string    `json:"player"` // Name of the character
+	Kind   string    `json:"kind"`   // One of the RosterJoined, RosterLeft, RosterGroupChanged or RosterLevelled kinds
+	From   string    `json:"from"`   // Previous group or level
+	To     string    `json:"to"`     // New group or level
+}
+
+// Kinds of roster changes
+const (
+	RosterJoined       = "joined"
+	RosterLeft         = "left"
+	RosterGroupChanged = "group"
+	RosterLevelled     = "level"
+)
+
+// Represents the death of a player during the raid
+type Death struct {
+	Player    string    `json:"player"`    // Name of the character that died
+	Killer    string    `json:"killer"`    // Name of the killer, when known
+	Time      time.Time `json:"time"`      // Time of death
+	Encounter string    `json:"encounter"` // Boss encounter the death occured during, when known
+}
+
+// Death messages for the same player closer together than this are treated as one death
+const duplicateDeathWindow = 10 * time.Second
+
+// Represents the monitored character entering a zone
+type ZoneChange struct {
+	Zone string    `json:"zone"`
+	Time time.Time `json:"time"`
+}
+
+// Represents a boss kill during the raid
+type Encounter struct {
+	Boss   string          `json:"boss"`   // Name of the slain boss
+	Killer string          `json:"killer"` // Character credited with the kill
+	Time   time.Time       `json:"time"`   // Time of the kill
+	Zone   string          `json:"zone"`   // Zone the kill took place in
+	Roster []string        `json:"roster"` // Players present in the latest raid dump at the time of the kill
+	Loot   []EncounterLoot `json:"loot"`   // Loot awarded within the encounter window
+}
+
+// Represents an item awarded from an encounter
+type EncounterLoot struct {
+	Player string `json:"player"`
+	Item   string `json:"item"`
 }
 
+// Kills of the same boss closer together than this are treated as one encounter
+const duplicateKillWindow = time.Minute
+
+// Chat check-ins older than this at the time of the next check-in are not credited
+const chatCheckinWindow = 15 * time.Minute
+
 func (raid *RaidCollection) AddRaid(newRaid Raid) error {
-	if !Active {
+	if !Current.IsActive() {
 		return fmt.Errorf("Raid is not active")
 	}
 	raid.RaidList = append(raid.RaidList, newRaid)
 	return nil
 }
 
-func Stop() error {
-	if !Active {
-		return fmt.Errorf("Raid is not active")
+// Ends the active or paused raid and adds it to the raid collection.
+// Publish a RaidStopped event afterwards to save it to file and the database
+func (s *Session) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.end("Stop")
+	if err != nil {
+		return err
 	}
-	ActiveRaid.CheckIn()
-	Active = false
-	ActiveRaid.Active = false
-	AllRaids.RaidList = append(AllRaids.RaidList, ActiveRaid)
-	err := ActiveRaid.AddToDB()
+	AllRaids.RaidList = append(AllRaids.RaidList, s.raid.copy())
+	return nil
+}
+
+// Ends the active raid without adding it to the raid collection, ie: for replayed raids
+func (s *Session) StopOffline() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.end("StopOffline")
+}
+
+// Credits a final check-in, unless the raid is paused, and ends the raid
+func (s *Session) end(action string) error {
+	recording := s.state == StateActive
+	err := s.transition(action)
+	if err != nil {
+		return err
+	}
+	if recording {
+		s.checkIn()
+	}
+	s.raid.Active = false
+	s.raid.Paused = false
+	return nil
+}
+
+// Pauses the active raid: raid dumps, loot and kills are not recorded until it is resumed
+func (s *Session) Pause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.transition("Pause")
 	if err != nil {
-		return fmt.Errorf("Stop(): ActiveRaid.AddToDB(): %w", err)
+		return err
 	}
-	err = ActiveRaid.SaveToFile()
+	s.raid.Paused = true
+	return nil
+}
+
+// Resumes recording the paused raid
+func (s *Session) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.transition("Resume")
 	if err != nil {
-		return fmt.Errorf("Stop(): ActiveRaid.SaveToFile(): %w", err)
+		return err
 	}
+	s.raid.Paused = false
 	return nil
 }
 
+// Applies the change (ie: a new name, description or tags) to the open raid.
+// A renamed raid is no longer named after the zones it visits.
+// Publish a RaidUpdated event afterwards to save it to file
+func (s *Session) Edit(change func(*Raid)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == StateIdle {
+		return fmt.Errorf("Edit(): the raid is %s", s.state)
+	}
+	change(&s.raid)
+	return nil
+}
+
+// Applies the change to a saved raid and writes it back to its file, the raid collection
+// and, once the raid has ended, the database
+func EditSavedRaid(fileName string, change func(*Raid)) error {
+	if Current.State() != StateIdle && Current.Raid().FileName == fileName {
+		return fmt.Errorf("EditSavedRaid(): %s is the open raid, edit it without a file name", fileName)
+	}
+	savedRaid, err := LoadRaid(fileName)
+	if err != nil {
+		return fmt.Errorf("EditSavedRaid(): %w", err)
+	}
+	change(&savedRaid)
+	err = SaveRaid(savedRaid)
+	if err != nil {
+		return fmt.Errorf("EditSavedRaid(): %w", err)
+	}
+	for index, collected := range AllRaids.RaidList {
+		if collected.FileName == fileName {
+			AllRaids.RaidList[index] = savedRaid.copy()
+		}
+	}
+	if savedRaid.Active {
+		return nil
+	}
+	err = savedRaid.UpdateInDB()
+	if err != nil {
+		return fmt.Errorf("EditSavedRaid(): %w", err)
+	}
+	return nil
+}
+
+// Adds tags to the raid, tags are lower case and never repeated
+func (raid *Raid) AddTags(tags ...string) {
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag != "" && !raid.HasTag(tag) {
+			raid.Tags = append(raid.Tags, tag)
+		}
+	}
+}
+
+// Removes tags from the raid
+func (raid *Raid) RemoveTags(tags ...string) {
+	kept := []string{}
+	for _, tag := range raid.Tags {
+		removed := false
+		for _, other := range tags {
+			if tag == normalizeTag(other) {
+				removed = true
+			}
+		}
+		if !removed {
+			kept = append(kept, tag)
+		}
+	}
+	raid.Tags = kept
+}
+
+// Returns true if the raid has the tag, regardless of case
+func (raid Raid) HasTag(tag string) bool {
+	return sliceContains(raid.Tags, normalizeTag(tag))
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// Returns the raid name followed by its tags, ie: "Plane of Fear [velious, alt night]"
+func (raid Raid) Title() string {
+	if len(raid.Tags) == 0 {
+		return raid.Name
+	}
+	return fmt.Sprintf("%s [%s]", raid.Name, strings.Join(raid.Tags, ", "))
+}
+
 // Forces the raid database to match the local raid collection
 func (raids *RaidCollection) UpdateDB() error {
 	if !mongodb.RaidsDB.Connected {
 		return fmt.Errorf("RaidCollection: UpdateDB(): raid database not connected")
 	}
-	fmt.Println("Dropping Raids Collection...")
+	logger.Infof(logger.Raid, "Dropping Raids Collection...")
 	mongodb.RaidsDB.Collection.Drop(mongodb.RaidsDB.Context)
 	for _, raid := range raids.RaidList {
-		fmt.Printf("Updating DB with raid: %s\n", raid.Name)
+		logger.Debugf(logger.Raid, "Updating DB with raid: %s", raid.Name)
 		err := mongodb.RaidsDB.Insert(raid)
 		if err != nil {
 			return fmt.Errorf("UpdateDB(): mongodb.RaidsDB.Insert(): %w", err)
@@ -106,7 +705,7 @@ func (raid Raid) AddToDB() error {
 		}
 	}
 	// Add provided raid to the raids database
-	fmt.Printf("Adding (%s) raid to the database...\n", raid.Name)
+	logger.Infof(logger.Raid, "Adding (%s) raid to the database...", raid.Name)
 	err := mongodb.RaidsDB.Insert(raid)
 	if err != nil {
 		return fmt.Errorf("AddToDB(): mongodb.RaidsDB.Insert(): %w", err)
@@ -118,6 +717,26 @@ func (raid Raid) AddToDB() error {
 	return nil
 }
 
+// Replaces the copy of the raid in the database, matched by file name
+func (raid Raid) UpdateInDB() error {
+	if !mongodb.RaidsDB.Connected {
+		err := mongodb.RaidsDB.Connect()
+		if err != nil {
+			return fmt.Errorf("UpdateInDB(): mongodb.RaidsDB.Connect(): %w", err)
+		}
+	}
+	logger.Infof(logger.Raid, "Updating (%s) raid in the database...", raid.Name)
+	err := mongodb.RaidsDB.Replace(bson.M{"filename": raid.FileName}, raid)
+	if err != nil {
+		return fmt.Errorf("UpdateInDB(): mongodb.RaidsDB.Replace(): %w", err)
+	}
+	err = mongodb.RaidsDB.Disconnect()
+	if err != nil {
+		return fmt.Errorf("UpdateInDB(): mongodb.RaidsDB.Disconnect(): %w", err)
+	}
+	return nil
+}
+
 // Loads the raids database into the Raid Collection
 func (raids *RaidCollection) LoadFromDB() error {
 	// Ensure the database is connected
@@ -152,39 +771,666 @@ func (raids *RaidCollection) LoadFromDB() error {
 	return nil
 }
 
-// Starts a new raid session
-func Start() error {
-	if Active {
-		return fmt.Errorf("Raid is already active")
+// Loads the ended raids of the SavedRaids folder into the Raid Collection, ie: when the database can't be reached
+func (raids *RaidCollection) LoadFromFiles() error {
+	savedRaidFiles, err := getSavedRaidFiles()
+	if err != nil {
+		return fmt.Errorf("LoadFromFiles(): %w", err)
 	}
-	Active = true
-	currentYear, currentMonth, currenteDay := time.Now().Date()
-	currentHour, currentMinute, currentSecond := time.Now().Clock()
-	activePlayers := core.GetActivePlayers()
+	loadedRaids := []Raid{}
+	for _, fileName := range savedRaidFiles {
+		savedRaid, err := LoadRaid(fileName)
+		if err != nil {
+			logger.Warnf(logger.Raid, "LoadFromFiles(): %s", err)
+			continue
+		}
+		if !savedRaid.Active {
+			loadedRaids = append(loadedRaids, savedRaid)
+		}
+	}
+	raids.RaidList = loadedRaids
+	return nil
+}
+
+// Selects raids from the raid history, empty fields select every raid
+type Filter struct {
+	From time.Time // Raids started before From are excluded
+	To   time.Time // Raids started at or after To are excluded
+	Zone string    // Raids that never entered a zone containing Zone are excluded, regardless of case
+	Tag  string    // Raids without the tag are excluded
+}
+
+// Returns true if the raid is selected by the filter
+func (filter Filter) Matches(raid Raid) bool {
+	start := raid.StartTime()
+	if !filter.From.IsZero() && start.Before(filter.From) {
+		return false
+	}
+	if !filter.To.IsZero() && !start.Before(filter.To) {
+		return false
+	}
+	if filter.Tag != "" && !raid.HasTag(filter.Tag) {
+		return false
+	}
+	if filter.Zone == "" {
+		return true
+	}
+	for _, change := range raid.Zones {
+		if strings.Contains(strings.ToLower(change.Zone), strings.ToLower(filter.Zone)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the raids selected by the filter, oldest first
+func (raids RaidCollection) Filter(filter Filter) []Raid {
+	selected := []Raid{}
+	for _, collected := range raids.RaidList {
+		if filter.Matches(collected) {
+			selected = append(selected, collected)
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].StartTime().Before(selected[j].StartTime())
+	})
+	return selected
+}
+
+// Returns the newest raid with the provided name or file name, regardless of case
+func (raids RaidCollection) Find(name string) (Raid, error) {
+	found := false
+	var newest Raid
+	for _, collected := range raids.RaidList {
+		if !strings.EqualFold(collected.Name, name) && !strings.EqualFold(collected.FileName, name) {
+			continue
+		}
+		if !found || collected.StartTime().After(newest.StartTime()) {
+			newest = collected
+			found = true
+		}
+	}
+	if !found {
+		return Raid{}, fmt.Errorf("Find(): no raid named %s", name)
+	}
+	return newest, nil
+}
+
+// Displays the raids selected by the filter, one per line
+func (raids RaidCollection) PrintList(filter Filter) error {
+	selected := raids.Filter(filter)
+	if len(selected) == 0 {
+		return fmt.Errorf("PrintList(): no raids found")
+	}
+	for index, collected := range selected {
+		fmt.Printf("%d) %s %s (%s): %d participants, %d encounters\n", index+1, collected.StartTime().Format("2006-01-02 15:04"), collected.Title(), collected.FileName, len(collected.Checkins), len(collected.Encounters))
+	}
+	return nil
+}
+
+// Displays the details of the raid: zones, check-ins, encounters and loot
+func (raid Raid) PrintDetails() {
+	fmt.Printf("%s (%s)\n", raid.Title(), raid.FileName)
+	if raid.Description != "" {
+		fmt.Println(raid.Description)
+	}
+	fmt.Printf("Started: %s, Duration: %d minutes\n", raid.StartTime().Format("2006-01-02 15:04"), int(raid.Duration().Minutes()))
+	if len(raid.Zones) > 0 {
+		fmt.Println("Zones:", raid.zoneTitle())
+	}
+	fmt.Print(raid.LeaderSummary())
+	names := []string{}
+	for name := range raid.Checkins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Println("Checkin Count:", len(names))
+	for index, name := range names {
+		fmt.Printf("%d) %s: %d, %.0f%% present\n", index+1, name, raid.Checkins[name], raid.Attendance[name].Percent)
+	}
+	if len(raid.Encounters) > 0 {
+		raid.PrintEncounters()
+	}
+	for _, p := range raid.Players {
+		for _, lootItem := range p.Loot {
+			fmt.Printf("%s -> %s (%s)\n", lootItem.Name, p.Name, lootItem.Method)
+		}
+	}
+}
+
+// Displays the raids the character, or any character sharing its handle, checked in to, oldest first
+func (raids RaidCollection) PrintPlayer(characterName string) error {
+	handle := alias.TryToGetHandle(characterName)
+	attended := 0
+	selected := raids.Filter(Filter{})
+	for _, collected := range selected {
+		names := []string{}
+		for name := range collected.Checkins {
+			if strings.EqualFold(alias.TryToGetHandle(name), handle) {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		attended++
+		sort.Strings(names)
+		for _, name := range names {
+			line := fmt.Sprintf("%s %s: %s checked in %d/%d times, %.0f%% present", collected.StartTime().Format("2006-01-02"), collected.Title(), name, collected.Checkins[name], collected.totalCheckins(), collected.Attendance[name].Percent)
+			if p := collected.GetPlayerByName(name); p != nil && len(p.Loot) > 0 {
+				items := []string{}
+				for _, lootItem := range p.Loot {
+					items = append(items, lootItem.Name)
+				}
+				line += ", loot: " + strings.Join(items, ", ")
+			}
+			fmt.Println(line)
+		}
+	}
+	if attended == 0 {
+		return fmt.Errorf("PrintPlayer(): %s has not attended any raid", characterName)
+	}
+	fmt.Printf("%s attended %d of %d raids\n", characterName, attended, len(selected))
+	return nil
+}
+
+// Rolling windows, in days, attendance is reported over. Zero covers the whole raid history
+var AttendanceWindows = []int{30, 60, 90, 0}
+
+// Represents the attendance of a handle over each of the AttendanceWindows
+type HandleAttendance struct {
+	Handle  string
+	Percent []float64 // Percent of the check-ins held in each window the handle was credited with
+}
+
+// Returns the attendance of every handle over the AttendanceWindows ending at the provided time,
+// highest 30 day attendance first. In each raid, a handle is credited with the check-ins of its best attending character
+func (raids RaidCollection) HandleAttendance(now time.Time) []HandleAttendance {
+	handles := []string{}
+	for _, collected := range raids.RaidList {
+		for name := range collected.Checkins {
+			handle := alias.TryToGetHandle(name)
+			if !sliceContains(handles, handle) {
+				handles = append(handles, handle)
+			}
+		}
+	}
+	report := []HandleAttendance{}
+	for _, handle := range handles {
+		attendance := HandleAttendance{Handle: handle}
+		for _, days := range AttendanceWindows {
+			filter := Filter{To: now}
+			if days > 0 {
+				filter.From = now.AddDate(0, 0, -days)
+			}
+			credited, held := 0, 0
+			for _, collected := range raids.Filter(filter) {
+				credited += collected.GetHighestCheckin(handle)
+				held += collected.totalCheckins()
+			}
+			percent := 0.0
+			if held > 0 {
+				percent = float64(credited) / float64(held) * 100
+			}
+			attendance.Percent = append(attendance.Percent, percent)
+		}
+		report = append(report, attendance)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Percent[0] == report[j].Percent[0] {
+			return report[i].Handle < report[j].Handle
+		}
+		return report[i].Percent[0] > report[j].Percent[0]
+	})
+	return report
+}
+
+// Returns the attendance report as lines of a table, starting with its header
+func FormatAttendance(report []HandleAttendance) []string {
+	header := fmt.Sprintf("%-16s", "Handle")
+	for _, days := range AttendanceWindows {
+		if days == 0 {
+			header += fmt.Sprintf(" %8s", "Lifetime")
+		} else {
+			header += fmt.Sprintf(" %8s", fmt.Sprintf("%dd", days))
+		}
+	}
+	lines := []string{header}
+	for _, attendance := range report {
+		line := fmt.Sprintf("%-16s", attendance.Handle)
+		for _, percent := range attendance.Percent {
+			line += fmt.Sprintf(" %7.0f%%", percent)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Returns the number of check-ins held during the raid, the most credited to any member
+func (raid Raid) totalCheckins() int {
+	total := 0
+	for _, checkIns := range raid.Checkins {
+		if checkIns > total {
+			total = checkIns
+		}
+	}
+	return total
+}
+
+// Returns the time the raid was started
+func (raid Raid) StartTime() time.Time {
+	return time.Date(raid.StartYear, time.Month(raid.StartMonth), raid.StartDay, raid.StartHour, raid.StartMinute, raid.StartSecond, 0, time.Local)
+}
+
+// Starts a new raid with the cached players.
+// Without a name, the raid is named after the zones it visits
+func (s *Session) Start(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.transition("Start")
+	if err != nil {
+		return err
+	}
+	currentYear, currentMonth, currenteDay := core.Now().Date()
+	currentHour, currentMinute, currentSecond := core.Now().Clock()
+	activePlayers := append([]*player.Player{}, s.players...)
 	// Initialize Active Raid struct
-	ActiveRaid = Raid{
-		Name:        "RaidAttend_" + strconv.Itoa(currentYear) + "-" + strconv.Itoa(int(currentMonth)) + "-" + strconv.Itoa(currenteDay) + "-" + strconv.Itoa(currentHour) + strconv.Itoa(currentMinute),
-		StartYear:   currentYear,
-		StartMonth:  int(currentMonth),
-		StartDay:    currenteDay,
-		StartHour:   currentHour,
-		StartMinute: currentMinute,
-		StartSecond: currentSecond,
-		Description: "",
-		Checkins:    make(map[string]int),
-		Players:     activePlayers,
-		FileName:    "RaidAttend_" + strconv.Itoa(currentYear) + "-" + strconv.Itoa(int(currentMonth)) + "-" + strconv.Itoa(currenteDay) + "-" + strconv.Itoa(currentHour) + strconv.Itoa(currentMinute) + ".json",
-		Active:      true}
+	s.raid = Raid{
+		Name:         "RaidAttend_" + strconv.Itoa(currentYear) + "-" + strconv.Itoa(int(currentMonth)) + "-" + strconv.Itoa(currenteDay) + "-" + strconv.Itoa(currentHour) + strconv.Itoa(currentMinute),
+		StartYear:    currentYear,
+		StartMonth:   int(currentMonth),
+		StartDay:     currenteDay,
+		StartHour:    currentHour,
+		StartMinute:  currentMinute,
+		StartSecond:  currentSecond,
+		Description:  "",
+		Checkins:     make(map[string]int),
+		ChatCheckins: make(map[string]int),
+		Players:      activePlayers,
+		FileName:     "RaidAttend_" + strconv.Itoa(currentYear) + "-" + strconv.Itoa(int(currentMonth)) + "-" + strconv.Itoa(currenteDay) + "-" + strconv.Itoa(currentHour) + strconv.Itoa(currentMinute) + ".json",
+		Active:       true}
+	if name != "" {
+		s.raid.Name = name
+	}
 	//-----------------------
-	ActiveRaid.initializeCheckins()
-	ActiveRaid.SaveToFile()
+	s.initializeCheckins()
+	return nil
+}
+
+// Updates the raid leader and master looter of the active raid from the players cache
+func (s *Session) UpdateLeaders() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	raidLeader, masterLooter := "", ""
+	for _, p := range s.players {
+		if p.RaidLeader {
+			raidLeader = p.Name
+		}
+		if p.MasterLooter {
+			masterLooter = p.Name
+		}
+	}
+	s.raid.RaidLeader = raidLeader
+	s.raid.MasterLooter = masterLooter
+}
+
+// Returns the raid leader and master looter of the raid as text, ie: for discord embeds
+func (raid Raid) LeaderSummary() string {
+	out := ""
+	if raid.RaidLeader != "" {
+		out += fmt.Sprintf("Raid Leader: %s\n", alias.TryToGetHandle(raid.RaidLeader))
+	}
+	if raid.MasterLooter != "" {
+		out += fmt.Sprintf("Master Looter: %s\n", alias.TryToGetHandle(raid.MasterLooter))
+	}
+	return out
+}
+
+// Records a zone change on the active raid.
+// While the raid has not been given a name of its own, it is named after the zones visited
+func (s *Session) EnterZone(zone string, enterTime time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if zone == "" || zone == s.raid.currentZone() {
+		return
+	}
+	autoNamed := s.raid.Name == strings.TrimSuffix(s.raid.FileName, ".json") || s.raid.Name == s.raid.zoneTitle()
+	s.raid.Zones = append(s.raid.Zones, ZoneChange{Zone: zone, Time: enterTime})
+	if autoNamed {
+		s.raid.Name = s.raid.zoneTitle()
+	}
+}
+
+// Returns the zone the raid is currently in
+func (raid Raid) CurrentZone() string {
+	return raid.currentZone()
+}
+
+func (raid Raid) currentZone() string {
+	if len(raid.Zones) == 0 {
+		return ""
+	}
+	return raid.Zones[len(raid.Zones)-1].Zone
+}
+
+// Returns the distinct zones of the raid in the order they were first entered, ie: "Plane of Fear / Plane of Hate"
+func (raid Raid) zoneTitle() string {
+	zones := []string{}
+	for _, change := range raid.Zones {
+		if !sliceContains(zones, change.Zone) {
+			zones = append(zones, change.Zone)
+		}
+	}
+	return strings.Join(zones, " / ")
+}
+
+func sliceContains(s []string, str string) bool {
+	for _, v := range s {
+		if v == str {
+			return true
+		}
+	}
+	return false
+}
+
+// Records a boss kill on the active raid, with a snapshot of the current roster.
+// Deaths within the window before the kill are linked to the encounter.
+// Returns false if the kill was already recorded (ie: seen in more than one message)
+func (s *Session) RecordEncounter(boss, killer string, killTime time.Time, window time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, encounter := range s.raid.Encounters {
+		if strings.EqualFold(encounter.Boss, boss) && killTime.Sub(encounter.Time) < duplicateKillWindow && encounter.Time.Sub(killTime) < duplicateKillWindow {
+			return false
+		}
+	}
+	roster := []string{}
+	for _, player := range s.players {
+		roster = append(roster, player.Name)
+	}
+	s.raid.Encounters = append(s.raid.Encounters, Encounter{
+		Boss:   boss,
+		Killer: killer,
+		Time:   killTime,
+		Zone:   s.raid.currentZone(),
+		Roster: roster,
+		Loot:   []EncounterLoot{}})
+	for index := range s.raid.Deaths {
+		death := &s.raid.Deaths[index]
+		elapsed := killTime.Sub(death.Time)
+		if death.Encounter == "" && elapsed >= 0 && elapsed <= window {
+			death.Encounter = boss
+		}
+	}
+	return true
+}
+
+// Records the death of a player on the active raid.
+// Returns false if the death was already recorded (ie: seen in more than one message)
+func (s *Session) RecordDeath(characterName, killer string, deathTime time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for index := range s.raid.Deaths {
+		death := &s.raid.Deaths[index]
+		if death.Player == characterName && deathTime.Sub(death.Time) < duplicateDeathWindow && death.Time.Sub(deathTime) < duplicateDeathWindow {
+			if death.Killer == "" {
+				death.Killer = killer
+			}
+			return false
+		}
+	}
+	s.raid.Deaths = append(s.raid.Deaths, Death{Player: characterName, Killer: killer, Time: deathTime})
+	return true
+}
+
+// Returns true if the character has been part of the active raid
+func (s *Session) IsRaidMember(characterName string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.raid.hasPlayer(characterName) {
+		return true
+	}
+	_, ok := s.raid.Checkins[characterName]
+	return ok
+}
+
+// Returns the number of deaths of each player in the raid
+func (raid Raid) DeathCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, death := range raid.Deaths {
+		counts[death.Player]++
+	}
+	return counts
+}
+
+// Displays the deaths of the raid in order, followed by the count for each player
+func (raid Raid) PrintDeaths() error {
+	if len(raid.Deaths) == 0 {
+		return fmt.Errorf("PrintDeaths(): no deaths have been recorded")
+	}
+	for index, death := range raid.Deaths {
+		fmt.Printf("%d) %s died at %s", index+1, death.Player, death.Time.Format("15:04:05"))
+		if death.Killer != "" {
+			fmt.Printf(" (slain by %s)", death.Killer)
+		}
+		if death.Encounter != "" {
+			fmt.Printf(" during %s", death.Encounter)
+		}
+		fmt.Println()
+	}
+	fmt.Println("Death Counts:")
+	for player, count := range raid.DeathCounts() {
+		fmt.Printf("%s: %d\n", player, count)
+	}
+	return nil
+}
+
+// Returns an end of raid summary suitable for a discord embed
+func (raid Raid) Summary() string {
+	out := fmt.Sprintf("Participants: %d\n", len(raid.Checkins))
+	out += fmt.Sprintf("Encounters: %d\n", len(raid.Encounters))
+	for _, encounter := range raid.Encounters {
+		out += fmt.Sprintf("- %s (%d items)\n", encounter.Boss, len(encounter.Loot))
+	}
+	out += fmt.Sprintf("Deaths: %d\n", len(raid.Deaths))
+	counts := raid.DeathCounts()
+	names := []string{}
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] == counts[names[j]] {
+			return names[i] < names[j]
+		}
+		return counts[names[i]] > counts[names[j]]
+	})
+	for _, name := range names {
+		out += fmt.Sprintf("- %s: %d\n", alias.TryToGetHandle(name), counts[name])
+	}
+	return out
+}
+
+// Records the members present in a raid dump. Members who were also present in the previous dump
+// have their presence extended up to this dump, others start a new presence interval
+func (s *Session) RecordPresence(names []string, dumpTime time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.raid.Presence == nil {
+		s.raid.Presence = make(map[string][]PresenceInterval)
+	}
+	for _, name := range names {
+		intervals := s.raid.Presence[name]
+		last := len(intervals) - 1
+		if last >= 0 && !s.raid.LastDump.IsZero() && intervals[last].End.Equal(s.raid.LastDump) {
+			intervals[last].End = dumpTime
+		} else {
+			intervals = append(intervals, PresenceInterval{Start: dumpTime, End: dumpTime})
+		}
+		s.raid.Presence[name] = intervals
+	}
+	if s.raid.FirstDump.IsZero() {
+		s.raid.FirstDump = dumpTime
+	}
+	s.raid.LastDump = dumpTime
+	s.raid.Attendance = s.raid.computeAttendance()
+}
+
+// Returns the time between the first and latest raid dumps
+func (raid Raid) Duration() time.Duration {
+	return raid.LastDump.Sub(raid.FirstDump)
+}
+
+// Returns the time weighted attendance of each member from their presence intervals
+func (raid Raid) computeAttendance() map[string]Attendance {
+	attendance := make(map[string]Attendance)
+	duration := raid.Duration()
+	for name, intervals := range raid.Presence {
+		var present time.Duration
+		for _, interval := range intervals {
+			present += interval.End.Sub(interval.Start)
+		}
+		percent := 0.0
+		if duration > 0 {
+			percent = float64(present) / float64(duration) * 100
+		}
+		attendance[name] = Attendance{Minutes: int(present.Minutes()), Percent: percent}
+	}
+	return attendance
+}
+
+// Returns the differences between the previous and current raid dump rosters, in roster order
+func DiffRosters(previous, current []*player.Player, dumpTime time.Time) []RosterChange {
+	changes := []RosterChange{}
+	previousByName := map[string]*player.Player{}
+	for _, p := range previous {
+		previousByName[p.Name] = p
+	}
+	currentByName := map[string]bool{}
+	for _, p := range current {
+		currentByName[p.Name] = true
+		old, ok := previousByName[p.Name]
+		if !ok {
+			changes = append(changes, RosterChange{Time: dumpTime, Player: p.Name, Kind: RosterJoined})
+			continue
+		}
+		if old.Group != p.Group {
+			changes = append(changes, RosterChange{Time: dumpTime, Player: p.Name, Kind: RosterGroupChanged, From: strconv.Itoa(old.Group), To: strconv.Itoa(p.Group)})
+		}
+		if old.Level != p.Level {
+			changes = append(changes, RosterChange{Time: dumpTime, Player: p.Name, Kind: RosterLevelled, From: strconv.Itoa(old.Level), To: strconv.Itoa(p.Level)})
+		}
+	}
+	for _, p := range previous {
+		if !currentByName[p.Name] {
+			changes = append(changes, RosterChange{Time: dumpTime, Player: p.Name, Kind: RosterLeft})
+		}
+	}
+	return changes
+}
+
+// Records roster changes on the active raid
+func (s *Session) RecordRosterChanges(changes []RosterChange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.raid.RosterChanges = append(s.raid.RosterChanges, changes...)
+}
+
+// Returns the roster change as text, ie: "Valgor moved from group 2 to group 4"
+func (change RosterChange) String() string {
+	switch change.Kind {
+	case RosterJoined:
+		return change.Player + " joined the raid"
+	case RosterLeft:
+		return change.Player + " left the raid"
+	case RosterGroupChanged:
+		return fmt.Sprintf("%s moved from group %s to group %s", change.Player, change.From, change.To)
+	case RosterLevelled:
+		return fmt.Sprintf("%s levelled from %s to %s", change.Player, change.From, change.To)
+	default:
+		return change.Player + " " + change.Kind
+	}
+}
+
+// Displays the roster changes of the raid, along with the last boss killed before each of them
+func (raid Raid) PrintRosterChanges() error {
+	if len(raid.RosterChanges) == 0 {
+		return fmt.Errorf("PrintRosterChanges(): no roster changes have been recorded")
+	}
+	for _, change := range raid.RosterChanges {
+		after := ""
+		for _, encounter := range raid.Encounters {
+			if encounter.Time.Before(change.Time) {
+				after = " (after " + encounter.Boss + ")"
+			}
+		}
+		fmt.Printf("[%s] %s%s\n", change.Time.Format("15:04:05"), change.String(), after)
+	}
 	return nil
 }
 
-// Create a func that displays all entries in the DisplayList map
-func PrintDisplayList() error {
-	if len(DisplayList) > 0 {
-		for character, checkins := range DisplayList {
+// Returns the boss of the most recent encounter killed within the window before lootTime,
+// or an empty string if no encounter matched
+func (s *Session) EncounterAt(lootTime time.Time, window time.Duration) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	encounter := s.encounterAt(lootTime, window)
+	if encounter == nil {
+		return ""
+	}
+	return encounter.Boss
+}
+
+// Links an item awarded at lootTime to the most recent encounter killed within the window before it.
+// Returns the name of the linked boss, or an empty string if no encounter matched
+func (s *Session) LinkLoot(playerName, itemName string, lootTime time.Time, window time.Duration) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	encounter := s.encounterAt(lootTime, window)
+	if encounter == nil {
+		return ""
+	}
+	encounter.Loot = append(encounter.Loot, EncounterLoot{Player: playerName, Item: itemName})
+	return encounter.Boss
+}
+
+func (s *Session) encounterAt(lootTime time.Time, window time.Duration) *Encounter {
+	for index := len(s.raid.Encounters) - 1; index >= 0; index-- {
+		encounter := &s.raid.Encounters[index]
+		elapsed := lootTime.Sub(encounter.Time)
+		if elapsed >= 0 && elapsed <= window {
+			return encounter
+		}
+	}
+	return nil
+}
+
+// Displays the encounters of the raid along with their loot
+func (raid Raid) PrintEncounters() error {
+	if len(raid.Encounters) == 0 {
+		return fmt.Errorf("PrintEncounters(): no encounters have been recorded")
+	}
+	for index, encounter := range raid.Encounters {
+		fmt.Printf("%d) %s slain at %s", index+1, encounter.Boss, encounter.Time.Format("15:04:05"))
+		if encounter.Zone != "" {
+			fmt.Printf(" in %s", encounter.Zone)
+		}
+		fmt.Printf(" (%d present)\n", len(encounter.Roster))
+		for _, loot := range encounter.Loot {
+			recipient := loot.Player
+			if recipient == "" {
+				recipient = "unassigned"
+			}
+			fmt.Printf("\t%s -> %s\n", loot.Item, recipient)
+		}
+	}
+	return nil
+}
+
+// Displays all entries in the display list
+func (s *Session) PrintDisplayList() error {
+	displayList := s.DisplayList()
+	if len(displayList) > 0 {
+		for character, checkins := range displayList {
 			fmt.Printf("%s: %d\n", character, checkins)
 		}
 	} else {
@@ -193,39 +1439,97 @@ func PrintDisplayList() error {
 	return nil
 }
 
-func (raid Raid) initializeCheckins() {
-	for _, player := range raid.Players {
-		ActiveRaid.Checkins[player.Name] = 1
+func (s *Session) initializeCheckins() {
+	for _, player := range s.raid.Players {
+		s.raid.Checkins[player.Name] = 1
+	}
+	for name := range s.takeChatCheckins() {
+		if _, ok := s.raid.Checkins[name]; !ok {
+			s.raid.Checkins[name] = 1
+			s.raid.creditChatCheckin(name)
+		}
 	}
-	//fmt.Printf("%d checkins initialized at a count of 1...\n", len(ActiveRaid.Checkins))
+	//fmt.Printf("%d checkins initialized at a count of 1...\n", len(s.raid.Checkins))
+}
+
+// Credits a check-in to the cached players and those who checked in through chat
+func (s *Session) CheckIn() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkIn()
 }
 
-func (raid Raid) CheckIn() error {
+func (s *Session) checkIn() error {
 
 	//Ensure Everyone in the raid is in the checkins map (default to 0)
-	for _, player := range raid.Players {
-		if _, ok := raid.Checkins[player.Name]; !ok {
-			raid.Checkins[player.Name] = 0
+	for _, player := range s.raid.Players {
+		if _, ok := s.raid.Checkins[player.Name]; !ok {
+			s.raid.Checkins[player.Name] = 0
+		}
+	}
+
+	//Ensure everyone who checked in through chat is in the checkins map
+	chatNames := s.takeChatCheckins()
+	for name := range chatNames {
+		if _, ok := s.raid.Checkins[name]; !ok {
+			s.raid.Checkins[name] = 0
 		}
 	}
 
 	//Increment checkinCounts
-	for playerName, checkIns := range raid.Checkins {
-		//Ensure player is on the current player list
-		if playerIsInCache(playerName) {
-			raid.Checkins[playerName] = checkIns + 1
+	for playerName, checkIns := range s.raid.Checkins {
+		//Ensure player is on the current player list, or confirmed through chat
+		if s.isCachedPlayer(playerName) {
+			s.raid.Checkins[playerName] = checkIns + 1
+		} else if chatNames[playerName] {
+			s.raid.Checkins[playerName] = checkIns + 1
+			s.raid.creditChatCheckin(playerName)
 		}
 	}
-	ActiveRaid.SaveToFile()
 	return nil
 }
 
+// Flags a character as checked in through chat at the provided time, to be credited at the next check-in
+// if it is held within chatCheckinWindow. The latest time is kept. Returns false if the character has already
+// checked in through chat within the window, so the repeat is not announced again
+func (s *Session) ChatCheckIn(characterName string, checkinTime time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, ok := s.chatCheckins[characterName]
+	s.chatCheckins[characterName] = checkinTime
+	return !ok || checkinTime.Sub(previous) > chatCheckinWindow
+}
+
+// Returns the pending chat check-ins and clears them
+func (s *Session) takeChatCheckins() map[string]bool {
+	now := core.Now()
+	names := map[string]bool{}
+	for name, checkinTime := range s.chatCheckins {
+		if now.Sub(checkinTime) <= chatCheckinWindow {
+			names[name] = true
+		}
+	}
+	s.chatCheckins = map[string]time.Time{}
+	return names
+}
+
+func (raid *Raid) creditChatCheckin(characterName string) {
+	if raid.ChatCheckins == nil {
+		raid.ChatCheckins = make(map[string]int)
+	}
+	raid.ChatCheckins[characterName]++
+}
+
 func (raid Raid) PrintLoot() {
 	// Display the loot for the raid
 	for _, player := range raid.Players {
 		fmt.Printf("Name: %s\nLoot:\n", player.Name)
 		for index, lootItem := range player.Loot {
-			fmt.Printf("%d) %s\n", index+1, lootItem.Name)
+			counted := ""
+			if lootItem.CountsAgainst {
+				counted = " [Counted]"
+			}
+			fmt.Printf("%d) %s (%s)%s\n", index+1, lootItem.Name, lootItem.Method, counted)
 		}
 	}
 }
@@ -240,25 +1544,41 @@ func (raid Raid) GetPlayerByName(playerName string) *player.Player {
 	return nil
 }
 
-func (raid Raid) PrintParticipation() error {
+// Displays the check-ins and attendance of each member of the active raid
+func (s *Session) PrintParticipation() error {
+	s.mu.Lock()
+	raid := s.raid.copy()
+	cached := map[string]bool{}
+	for _, p := range s.players {
+		cached[p.Name] = true
+	}
+	s.mu.Unlock()
 	if !raid.Active {
 		return fmt.Errorf("Raid is not active")
 	}
 	if len(raid.Players) == 0 {
 		return fmt.Errorf("there are no players in the raid")
 	}
-	fileName := raid.Name + ".json"
-	fmt.Println("Print Raid: " + fileName)
+	fmt.Printf("Print Raid: %s (%s)\n", raid.Name, raid.FileName)
+	fmt.Print(raid.LeaderSummary())
+	for _, change := range raid.Zones {
+		fmt.Printf("Entered %s at %s\n", change.Zone, change.Time.Format("15:04:05"))
+	}
 	//Increment checkinCounts
-	fmt.Println("Checkin Count:", len(ActiveRaid.Checkins))
+	fmt.Println("Checkin Count:", len(raid.Checkins))
+	fmt.Printf("Raid Duration: %d minutes\n", int(raid.Duration().Minutes()))
 	index := 1
-	for playerName, checkIns := range ActiveRaid.Checkins {
+	for playerName, checkIns := range raid.Checkins {
 		//fmt.Printf("%s has checked in %d times\n", playerName, checkIns)
+		attendance := raid.Attendance[playerName]
+		present := fmt.Sprintf("%d min (%.0f%%)", attendance.Minutes, attendance.Percent)
 		//Ensure player is on the current player list
-		if playerIsInCache(playerName) {
-			fmt.Printf("%d) %s: %d [Active]\n", index, playerName, checkIns)
+		if cached[playerName] {
+			fmt.Printf("%d) %s: %d, %s [Active]\n", index, playerName, checkIns, present)
+		} else if raid.ChatCheckins[playerName] > 0 {
+			fmt.Printf("%d) %s: %d, %s [Chat: %d]\n", index, playerName, checkIns, present, raid.ChatCheckins[playerName])
 		} else {
-			fmt.Printf("%d) %s: %d [Inactive]\n", index, playerName, checkIns)
+			fmt.Printf("%d) %s: %d, %s [Inactive]\n", index, playerName, checkIns, present)
 		}
 		index++
 	}
@@ -274,12 +1594,11 @@ func (raid Raid) SaveToFile() error {
 }
 
 func (raid Raid) GetCheckinsByName(name string) int {
-	mu.Lock()
-	defer mu.Unlock()
 	return raid.Checkins[name]
 }
 
-func (raid Raid) Load(savedRaid string) error {
+// Loads the provided saved raid file, or the newest one if none is provided, into the session
+func (s *Session) Load(savedRaid string) error {
 	var err error
 	if savedRaid == "" {
 		savedRaid, err = getLastRaidFile()
@@ -295,10 +1614,12 @@ func (raid Raid) Load(savedRaid string) error {
 	if err != nil {
 		return fmt.Errorf("error loading raid: %s", err)
 	}
-	ActiveRaid = loadedRaid
-	core.Players = ActiveRaid.Players
+	s.mu.Lock()
+	s.raid = loadedRaid
+	s.players = s.raid.Players
+	s.mu.Unlock()
 
-	fmt.Println("Loaded Raid: " + ActiveRaid.Name)
+	fmt.Println("Loaded Raid: " + loadedRaid.Name)
 	return nil
 }
 
@@ -338,13 +1659,11 @@ func getLastRaidFile() (string, error) {
 }
 
 func getSavedRaidFiles() ([]string, error) {
-	// Get the directory of the current executable
-	EQpath, err := os.Getwd()
+	// Get the directory of the SavedRaids folder
+	savedRaidsFolder, err := config.GetSavedRaidsDir()
 	if err != nil {
-		return []string{}, fmt.Errorf("getSavedRaidFiles(): os.getwd: %w", err)
+		return []string{}, fmt.Errorf("getSavedRaidFiles(): %w", err)
 	}
-	// Get the directory of the RaidLogs folder
-	savedRaidsFolder := EQpath + "\\SavedRaids"
 
 	// Step through files and look for Raid Dump files
 	savedRaidsFileList := []string{}
@@ -367,15 +1686,13 @@ func getSavedRaidFiles() ([]string, error) {
 }
 
 func getSavedRaidModDate(raidFilePath string) (string, error) {
-	// Get the directory of the current executable
-	EQpath, err := os.Getwd()
+	// Get the directory of the SavedRaids folder
+	savedRaidsFolder, err := config.GetSavedRaidsDir()
 	if err != nil {
-		return "", fmt.Errorf("getSavedRaidModDate(): os.getwd: %w", err)
+		return "", fmt.Errorf("getSavedRaidModDate(): %w", err)
 	}
-	// Get the directory of the SavedRaids folder
-	raidLogsFolder := EQpath + "\\SavedRaids"
 
-	fileStat, err := os.Stat(raidLogsFolder + "\\" + raidFilePath)
+	fileStat, err := os.Stat(filepath.Join(savedRaidsFolder, raidFilePath))
 	if err != nil {
 		return "", fmt.Errorf("getSavedRaidModDate(%s): os.Stat: %w", raidFilePath, err)
 	}
@@ -463,51 +1780,44 @@ func parseFileDateTime(dateTime string) (int, int, int, int, int, int, error) {
 	return year, month, day, hour, minute, second, nil
 }
 
-func playerIsInCache(name string) bool {
-	for _, player := range core.GetActivePlayers() {
-		if player.Name == name {
-			return true
+func (s *Session) AddPlayersToRaid() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Ensure all cached players are also in the raid players
+	for _, player := range s.players {
+		if !s.raid.hasPlayer(player.Name) {
+			logger.Infof(logger.Raid, "Adding %s to raid...", player.Name)
+			s.raid.Players = append(s.raid.Players, player)
 		}
 	}
-	return false
 }
 
-func AddPlayersToRaid() {
-	// Ensure all players in core.Players are also in ActiveRaid.Players
-	for _, player := range core.Players {
-		if !PlayerIsInRaid(player.Name) {
-			fmt.Printf("Adding %s to raid...\n", player.Name)
-			ActiveRaid.Players = append(ActiveRaid.Players, player)
-		}
-	}
-}
-
-func PlayerIsInDisplayList(characterName string) bool {
-	// Check if the provided characterName is in the DisplayList map
-	_, ok := DisplayList[characterName]
-	return ok
+// Updates the display list with cached player information, enforcing alias
+func (s *Session) UpdateDisplayList() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateDisplayList()
 }
 
-// Updates the DisplayList map with cached player information, enforcing alias
-func UpdateDisplayList() {
-	// Clear the DisplayList map
-	DisplayList = make(map[string]int)
+func (s *Session) updateDisplayList() {
+	// Clear the display list
+	s.displayList = make(map[string]int)
 	// Iterate through the checkins map
-	for character := range ActiveRaid.Checkins {
+	for character := range s.raid.Checkins {
 		handle := alias.TryToGetHandle(character)
-		// If the handle of the checkin character is not in the DisplayList map
-		if !PlayerIsInDisplayList(handle) {
-			// Add the handle of the checkin character to the DisplayList map
-			DisplayList[handle] = GetHighestCheckin(handle)
-			fmt.Printf("Adding %s to DisplayList...\n", handle)
+		// If the handle of the checkin character is not in the display list
+		if _, ok := s.displayList[handle]; !ok {
+			// Add the handle of the checkin character to the display list
+			s.displayList[handle] = s.raid.GetHighestCheckin(handle)
+			logger.Debugf(logger.Raid, "Adding %s to DisplayList...", handle)
 		}
 	}
 }
 
 // Return the highest checkin associated with the handle specified
-func GetHighestCheckin(handle string) int {
+func (raid Raid) GetHighestCheckin(handle string) int {
 	highestCheckin := 0
-	for character, checkins := range ActiveRaid.Checkins {
+	for character, checkins := range raid.Checkins {
 		// Check if the character is associated with the provided handler
 		if alias.TryToGetHandle(character) == handle {
 			if checkins > highestCheckin {
@@ -519,8 +1829,14 @@ func GetHighestCheckin(handle string) int {
 }
 
 // Detirmine if the provided character name is present in the active raid
-func PlayerIsInRaid(characterName string) bool {
-	for _, player := range ActiveRaid.Players {
+func (s *Session) PlayerIsInRaid(characterName string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.raid.hasPlayer(characterName)
+}
+
+func (raid Raid) hasPlayer(characterName string) bool {
+	for _, player := range raid.Players {
 		if player.Name == characterName {
 			return true
 		}
@@ -530,16 +1846,14 @@ func PlayerIsInRaid(characterName string) bool {
 
 // Saves the provided raid to a json file
 func SaveRaid(raid Raid) error {
-	fmt.Printf("Saving (%s) to raid file...\n", raid.Name)
+	logger.Debugf(logger.Raid, "Saving (%s) to raid file...", raid.Name)
 
-	EQpath, err := os.Getwd()
+	// Get SavedRaids folder
+	savedRaidsFolder, err := config.GetSavedRaidsDir()
 	if err != nil {
-		return fmt.Errorf("organizeRaidDumps(): os.getwd: %w", err)
+		return fmt.Errorf("SaveRaid(): %w", err)
 	}
 
-	// Get SavedRaids folder
-	savedRaidsFolder := EQpath + "\\SavedRaids"
-
 	file, err := json.MarshalIndent(raid, "", " ")
 	if err != nil {
 		return fmt.Errorf("SaveRaid(): failed to marshal raid: %w", err)
@@ -547,33 +1861,31 @@ func SaveRaid(raid Raid) error {
 
 	//fmt.Println(string(file))
 
-	err = ioutil.WriteFile(savedRaidsFolder+"\\"+raid.FileName, file, 0644)
+	err = ioutil.WriteFile(filepath.Join(savedRaidsFolder, raid.FileName), file, 0644)
 
 	if err != nil {
 		return fmt.Errorf("SaveRaid(): failed to write to raid file: %w", err)
 	}
 
-	fmt.Println("Raid save successful!")
+	logger.Debugf(logger.Raid, "Raid save successful!")
 	return nil
 }
 
 // Load the provided raid from a json file
 func LoadRaid(fileName string) (Raid, error) {
-	fmt.Printf("Loading (%s) from raid file...\n", fileName)
+	logger.Infof(logger.Raid, "Loading (%s) from raid file...", fileName)
 
 	if fileName == "" {
 		return Raid{}, fmt.Errorf("LoadRaid(): no file name provided")
 	}
 
-	EQpath, err := os.Getwd()
+	// Get SavedRaids folder
+	savedRaidsFolder, err := config.GetSavedRaidsDir()
 	if err != nil {
-		return Raid{}, fmt.Errorf("LoadRaid(): os.getwd: %w", err)
+		return Raid{}, fmt.Errorf("LoadRaid(): %w", err)
 	}
 
-	// Get SavedRaids folder
-	savedRaidsFolder := EQpath + "\\SavedRaids"
-
-	file, err := ioutil.ReadFile(savedRaidsFolder + "\\" + fileName)
+	file, err := ioutil.ReadFile(filepath.Join(savedRaidsFolder, fileName))
 	if err != nil {
 		return Raid{}, fmt.Errorf("LoadRaid(): failed to read raid file: %w", err)
 	}
@@ -584,6 +1896,6 @@ func LoadRaid(fileName string) (Raid, error) {
 		return Raid{}, fmt.Errorf("LoadRaid(): failed to unmarshal raid: %w", err)
 	}
 
-	fmt.Println("Raid load successful!")
+	logger.Infof(logger.Raid, "Raid load successful!")
 	return raid, nil
 }
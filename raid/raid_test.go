@@ -0,0 +1,266 @@
+package raid
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Valorith/EQRaidAssist/config"
+	"github.com/Valorith/EQRaidAssist/core"
+	"github.com/Valorith/EQRaidAssist/player"
+)
+
+func TestRecordPresence(t *testing.T) {
+	session := NewSession()
+	start := time.Date(2022, time.February, 16, 20, 0, 0, 0, time.Local)
+	session.RecordPresence([]string{"Valgor", "Leaver"}, start)
+	session.RecordPresence([]string{"Valgor", "Leaver"}, start.Add(30*time.Minute))
+	session.RecordPresence([]string{"Valgor", "Late"}, start.Add(60*time.Minute))
+	session.RecordPresence([]string{"Valgor", "Late", "Leaver"}, start.Add(90*time.Minute))
+	session.RecordPresence([]string{"Valgor", "Late", "Leaver"}, start.Add(120*time.Minute))
+
+	expected := map[string]Attendance{
+		"Valgor": {Minutes: 120, Percent: 100},
+		"Leaver": {Minutes: 60, Percent: 50},
+		"Late":   {Minutes: 60, Percent: 50},
+	}
+	raid := session.Raid()
+	for name, attendance := range expected {
+		if raid.Attendance[name] != attendance {
+			t.Errorf("%s: attendance = %+v, expected %+v", name, raid.Attendance[name], attendance)
+		}
+	}
+	if len(raid.Presence["Leaver"]) != 2 {
+		t.Errorf("Leaver: %d presence intervals, expected 2", len(raid.Presence["Leaver"]))
+	}
+	if Current.Raid().Presence != nil {
+		t.Errorf("recording presence on a new session changed the current session")
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	session := NewSession()
+	if session.Pause() == nil || session.Resume() == nil || session.StopOffline() == nil {
+		t.Errorf("an idle raid was paused, resumed or ended")
+	}
+	err := session.Start("Plane of Fear")
+	if err != nil {
+		t.Fatalf("Start: %s", err)
+	}
+	if session.Start("") == nil {
+		t.Errorf("an active raid was started again")
+	}
+	if session.Raid().Name != "Plane of Fear" {
+		t.Errorf("name = %q, expected %q", session.Raid().Name, "Plane of Fear")
+	}
+	err = session.Pause()
+	if err != nil {
+		t.Fatalf("Pause: %s", err)
+	}
+	if session.IsActive() || session.State() != StatePaused || !session.Raid().Paused {
+		t.Errorf("paused raid is %s", session.State())
+	}
+	err = session.Resume()
+	if err != nil {
+		t.Fatalf("Resume: %s", err)
+	}
+	err = session.StopOffline()
+	if err != nil {
+		t.Fatalf("StopOffline: %s", err)
+	}
+	if session.State() != StateIdle || session.Raid().Active {
+		t.Errorf("ended raid is %s", session.State())
+	}
+}
+
+func TestRecover(t *testing.T) {
+	config.SavedRaidsDir = t.TempDir()
+	defer func() { config.SavedRaidsDir = "" }()
+	start := time.Date(2022, time.February, 16, 20, 0, 0, 0, time.Local)
+	ended := Raid{Name: "Ended", FileName: "RaidAttend_ended.json"}
+	unfinished := Raid{
+		Name:     "Plane of Fear",
+		FileName: "RaidAttend_unfinished.json",
+		Active:   true,
+		Checkins: map[string]int{"Valgor": 2, "Leaver": 1},
+		Players:  []*player.Player{{Name: "Valgor"}, {Name: "Leaver"}},
+		Presence: map[string][]PresenceInterval{
+			"Valgor": {{Start: start, End: start.Add(time.Hour)}},
+			"Leaver": {{Start: start, End: start.Add(30 * time.Minute)}},
+		},
+		FirstDump: start,
+		LastDump:  start.Add(time.Hour),
+	}
+	for _, saved := range []Raid{ended, unfinished} {
+		err := SaveRaid(saved)
+		if err != nil {
+			t.Fatalf("SaveRaid: %s", err)
+		}
+	}
+	fileName, err := FindUnfinishedRaid()
+	if err != nil {
+		t.Fatalf("FindUnfinishedRaid: %s", err)
+	}
+	if fileName != unfinished.FileName {
+		t.Fatalf("unfinished raid = %q, expected %q", fileName, unfinished.FileName)
+	}
+	session := NewSession()
+	err = session.Recover(fileName)
+	if err != nil {
+		t.Fatalf("Recover: %s", err)
+	}
+	if !session.IsActive() || session.Raid().Checkins["Valgor"] != 2 {
+		t.Errorf("recovered raid is %s with %d Valgor check-ins", session.State(), session.Raid().Checkins["Valgor"])
+	}
+	players := session.Players()
+	if len(players) != 1 || players[0].Name != "Valgor" {
+		t.Errorf("recovered %d cached players, expected only Valgor", len(players))
+	}
+	if session.Recover(ended.FileName) == nil {
+		t.Errorf("an ended raid was recovered")
+	}
+}
+
+func TestTags(t *testing.T) {
+	tagged := Raid{Name: "Plane of Fear"}
+	tagged.AddTags("Velious", " alt night", "velious")
+	if tagged.Title() != "Plane of Fear [velious, alt night]" {
+		t.Errorf("title = %q, expected %q", tagged.Title(), "Plane of Fear [velious, alt night]")
+	}
+	tagged.RemoveTags("VELIOUS")
+	if tagged.HasTag("velious") || !tagged.HasTag("Alt Night") {
+		t.Errorf("tags = %v, expected [alt night]", tagged.Tags)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	fear := Raid{Name: "Fear", StartYear: 2022, StartMonth: 2, StartDay: 16, Zones: []ZoneChange{{Zone: "Plane of Fear"}}}
+	hate := Raid{Name: "Hate", StartYear: 2022, StartMonth: 2, StartDay: 2, Tags: []string{"alt night"}, Zones: []ZoneChange{{Zone: "Plane of Hate"}}}
+	raids := RaidCollection{RaidList: []Raid{fear, hate}}
+	tests := []struct {
+		filter   Filter
+		expected []string
+	}{
+		{Filter{}, []string{"Hate", "Fear"}},
+		{Filter{From: time.Date(2022, 2, 10, 0, 0, 0, 0, time.Local)}, []string{"Fear"}},
+		{Filter{To: time.Date(2022, 2, 16, 0, 0, 0, 0, time.Local)}, []string{"Hate"}},
+		{Filter{Zone: "fear"}, []string{"Fear"}},
+		{Filter{Tag: "Alt Night"}, []string{"Hate"}},
+	}
+	for _, test := range tests {
+		names := []string{}
+		for _, selected := range raids.Filter(test.filter) {
+			names = append(names, selected.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("Filter(%+v) = %v, expected %v", test.filter, names, test.expected)
+		}
+	}
+}
+
+func TestHandleAttendance(t *testing.T) {
+	now := time.Date(2022, time.March, 1, 0, 0, 0, 0, time.Local)
+	recent := Raid{StartYear: 2022, StartMonth: 2, StartDay: 20, Checkins: map[string]int{"Valgor": 4, "Leaver": 2}}
+	old := Raid{StartYear: 2021, StartMonth: 10, StartDay: 1, Checkins: map[string]int{"Valgor": 2, "Leaver": 6}}
+	raids := RaidCollection{RaidList: []Raid{recent, old}}
+	expected := map[string][]float64{
+		"Valgor": {100, 100, 100, 60},
+		"Leaver": {50, 50, 50, 80},
+	}
+	report := raids.HandleAttendance(now)
+	if len(report) != 2 || report[0].Handle != "Valgor" {
+		t.Fatalf("report = %+v, expected Valgor then Leaver", report)
+	}
+	for _, attendance := range report {
+		for index, percent := range attendance.Percent {
+			if percent != expected[attendance.Handle][index] {
+				t.Errorf("%s attendance = %v, expected %v", attendance.Handle, attendance.Percent, expected[attendance.Handle])
+				break
+			}
+		}
+	}
+}
+
+func TestDiffRosters(t *testing.T) {
+	dumpTime := time.Date(2022, time.February, 16, 20, 0, 0, 0, time.Local)
+	previous := []*player.Player{{Name: "Valgor", Group: 1, Level: 60}, {Name: "Healer", Group: 2, Level: 59}}
+	tests := []struct {
+		name     string
+		current  []*player.Player
+		expected []RosterChange
+	}{
+		{
+			name:     "unchanged",
+			current:  []*player.Player{{Name: "Valgor", Group: 1, Level: 60}, {Name: "Healer", Group: 2, Level: 59}},
+			expected: []RosterChange{},
+		},
+		{
+			name:     "joined",
+			current:  []*player.Player{{Name: "Valgor", Group: 1, Level: 60}, {Name: "Healer", Group: 2, Level: 59}, {Name: "Late", Group: 3, Level: 60}},
+			expected: []RosterChange{{Time: dumpTime, Player: "Late", Kind: RosterJoined}},
+		},
+		{
+			name:     "left",
+			current:  []*player.Player{{Name: "Valgor", Group: 1, Level: 60}},
+			expected: []RosterChange{{Time: dumpTime, Player: "Healer", Kind: RosterLeft}},
+		},
+		{
+			name:     "group changed",
+			current:  []*player.Player{{Name: "Valgor", Group: 1, Level: 60}, {Name: "Healer", Group: 1, Level: 59}},
+			expected: []RosterChange{{Time: dumpTime, Player: "Healer", Kind: RosterGroupChanged, From: "2", To: "1"}},
+		},
+		{
+			name:     "levelled",
+			current:  []*player.Player{{Name: "Valgor", Group: 1, Level: 60}, {Name: "Healer", Group: 2, Level: 60}},
+			expected: []RosterChange{{Time: dumpTime, Player: "Healer", Kind: RosterLevelled, From: "59", To: "60"}},
+		},
+	}
+	for _, test := range tests {
+		changes := DiffRosters(previous, test.current, dumpTime)
+		if len(changes) != len(test.expected) {
+			t.Errorf("%s: changes = %v, expected %v", test.name, changes, test.expected)
+			continue
+		}
+		for index, change := range changes {
+			if change != test.expected[index] {
+				t.Errorf("%s: change %d = %+v, expected %+v", test.name, index, change, test.expected[index])
+			}
+		}
+	}
+}
+
+func TestChatCheckInWindow(t *testing.T) {
+	now := time.Date(2022, time.February, 16, 21, 0, 0, 0, time.Local)
+	core.SetClock(func() time.Time { return now })
+	defer core.SetClock(nil)
+
+	session := NewSession()
+	err := session.Start("Plane of Hate")
+	if err != nil {
+		t.Fatalf("Start: %s", err)
+	}
+	if !session.ChatCheckIn("Stale", now.Add(-time.Hour)) || !session.ChatCheckIn("Recent", now.Add(-time.Minute)) {
+		t.Fatalf("a first chat check-in was rejected")
+	}
+	if session.ChatCheckIn("Recent", now) {
+		t.Errorf("a repeated chat check-in within the window was accepted")
+	}
+	// Typed 20 minutes before the check-in and again 6 minutes before it, the repeat keeps it recent
+	if !session.ChatCheckIn("Repeated", now.Add(-20*time.Minute)) || session.ChatCheckIn("Repeated", now.Add(-6*time.Minute)) {
+		t.Errorf("Repeated: only the first chat check-in should be announced")
+	}
+	err = session.CheckIn()
+	if err != nil {
+		t.Fatalf("CheckIn: %s", err)
+	}
+	checkins := session.Raid().Checkins
+	if checkins["Recent"] != 1 {
+		t.Errorf("Recent: %d check-ins, expected 1", checkins["Recent"])
+	}
+	if checkins["Repeated"] != 1 {
+		t.Errorf("Repeated: %d check-ins, expected 1", checkins["Repeated"])
+	}
+	if checkins["Stale"] != 0 {
+		t.Errorf("Stale: %d check-ins, expected 0", checkins["Stale"])
+	}
+}
@@ -0,0 +1,170 @@
+// Package roll tracks /random roll sessions used to distribute loot.
+// Sessions are keyed by their roll range, so several items can be rolled at once
+// by giving each of them a distinct range.
+package roll
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	mu       sync.Mutex
+	sessions = map[string]*Session{} // Open roll sessions keyed by range
+)
+
+func ResetData() {
+	mu.Lock()
+	defer mu.Unlock()
+	sessions = map[string]*Session{}
+}
+
+// Represents a single /random result
+type Roll struct {
+	Player string    `json:"player"`
+	Value  int       `json:"value"`
+	Time   time.Time `json:"time"`
+}
+
+// Represents an item being rolled for
+type Session struct {
+	Item   string    `json:"item"`   // Item being rolled for
+	Low    int       `json:"low"`    // Lowest possible roll
+	High   int       `json:"high"`   // Highest possible roll
+	Opened time.Time `json:"opened"` // Time the session was opened, earlier rolls are ignored
+	Rolls  []Roll    `json:"rolls"`  // First roll of each player
+}
+
+// Returns the key of the provided range, ie: 0-100
+func Key(low, high int) string {
+	return strconv.Itoa(low) + "-" + strconv.Itoa(high)
+}
+
+// ParseRange converts a range argument into its bounds.
+// Accepts either a maximum ("333", rolled with /random 333) or explicit bounds ("1-333")
+func ParseRange(value string) (int, int, error) {
+	lowString, highString := "0", value
+	if index := strings.Index(value, "-"); index >= 0 {
+		lowString, highString = value[:index], value[index+1:]
+	}
+	low, err := strconv.Atoi(lowString)
+	if err != nil {
+		return 0, 0, fmt.Errorf("ParseRange(): invalid low value: %w", err)
+	}
+	high, err := strconv.Atoi(highString)
+	if err != nil {
+		return 0, 0, fmt.Errorf("ParseRange(): invalid high value: %w", err)
+	}
+	if low < 0 || high <= low {
+		return 0, 0, fmt.Errorf("ParseRange(): invalid range: %d-%d", low, high)
+	}
+	return low, high, nil
+}
+
+// Opens a roll session for the item on the provided range.
+// Log timestamps are whole seconds, so the open time is truncated to keep rolls made in the same second
+func Open(item string, low, high int, opened time.Time) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if item == "" {
+		return fmt.Errorf("Open(): no item provided")
+	}
+	key := Key(low, high)
+	if session, ok := sessions[key]; ok {
+		return fmt.Errorf("Open(): a roll for %s is already open on %s", session.Item, key)
+	}
+	sessions[key] = &Session{Item: item, Low: low, High: high, Opened: opened.Truncate(time.Second), Rolls: []Roll{}}
+	return nil
+}
+
+// Closes the roll session on the provided range and returns it
+func Close(low, high int) (*Session, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	key := Key(low, high)
+	session, ok := sessions[key]
+	if !ok {
+		return nil, fmt.Errorf("Close(): no roll is open on %s", key)
+	}
+	delete(sessions, key)
+	return session, nil
+}
+
+// Records a roll against the open session on its range.
+// Returns false if no session is open on the range, the value is outside the range,
+// the roll predates the session or the player already rolled (only the first roll counts)
+func Record(player string, low, high, value int, rollTime time.Time) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	session, ok := sessions[Key(low, high)]
+	if !ok || value < low || value > high || rollTime.Before(session.Opened) {
+		return false
+	}
+	for _, r := range session.Rolls {
+		if r.Player == player {
+			return false
+		}
+	}
+	session.Rolls = append(session.Rolls, Roll{Player: player, Value: value, Time: rollTime})
+	return true
+}
+
+// Returns a copy of the open sessions, ordered by range
+func List() []Session {
+	mu.Lock()
+	defer mu.Unlock()
+	keys := []string{}
+	for key := range sessions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	list := []Session{}
+	for _, key := range keys {
+		session := *sessions[key]
+		session.Rolls = append([]Roll{}, session.Rolls...)
+		list = append(list, session)
+	}
+	return list
+}
+
+// Returns the rolls of the session from highest to lowest, earlier rolls win ties
+func (s Session) Ranked() []Roll {
+	ranked := append([]Roll{}, s.Rolls...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Value == ranked[j].Value {
+			return ranked[i].Time.Before(ranked[j].Time)
+		}
+		return ranked[i].Value > ranked[j].Value
+	})
+	return ranked
+}
+
+// Returns the winning roll of the session, false if nobody rolled
+func (s Session) Winner() (Roll, bool) {
+	ranked := s.Ranked()
+	if len(ranked) == 0 {
+		return Roll{}, false
+	}
+	return ranked[0], true
+}
+
+// Returns the ranked results of the session as text
+func (s Session) Results() string {
+	winner, ok := s.Winner()
+	if !ok {
+		return fmt.Sprintf("Nobody rolled for %s (%s)", s.Item, Key(s.Low, s.High))
+	}
+	out := fmt.Sprintf("%s wins %s with a %d!\n----------------\n", winner.Player, s.Item, winner.Value)
+	for index, r := range s.Ranked() {
+		tied := ""
+		if index > 0 && r.Value == winner.Value {
+			tied = " (tied, rolled later)"
+		}
+		out += fmt.Sprintf("%d) %s: %d%s\n", index+1, r.Player, r.Value, tied)
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package roll
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	ResetData()
+	defer ResetData()
+	opened := time.Date(2022, time.February, 16, 20, 0, 0, 0, time.Local)
+	err := Open("Cloak of Flames", 0, 333, opened)
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	if Open("Efreeti War Axe", 0, 333, opened) == nil {
+		t.Errorf("a second roll was opened on the same range")
+	}
+	tests := []struct {
+		player   string
+		low      int
+		high     int
+		value    int
+		time     time.Time
+		recorded bool
+	}{
+		{"Valgor", 0, 333, 200, opened.Add(time.Second), true},
+		{"Valgor", 0, 333, 300, opened.Add(2 * time.Second), false}, // Only the first roll counts
+		{"Healer", 0, 333, 200, opened.Add(3 * time.Second), true},  // Ties with Valgor, rolled later
+		{"Early", 0, 333, 100, opened.Add(-time.Second), false},     // Rolled before the session opened
+		{"Cheater", 0, 333, 334, opened.Add(4 * time.Second), false},
+		{"Other", 0, 100, 50, opened.Add(5 * time.Second), false}, // No session on the range
+		{"Late", 0, 333, 150, opened.Add(6 * time.Second), true},
+	}
+	for _, test := range tests {
+		if Record(test.player, test.low, test.high, test.value, test.time) != test.recorded {
+			t.Errorf("Record(%s, %d): recorded = %t, expected %t", test.player, test.value, !test.recorded, test.recorded)
+		}
+	}
+
+	session, err := Close(0, 333)
+	if err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+	ranked := session.Ranked()
+	if len(ranked) != 3 || ranked[0].Player != "Valgor" || ranked[1].Player != "Healer" || ranked[2].Player != "Late" {
+		t.Errorf("ranked = %+v, expected Valgor, Healer, Late", ranked)
+	}
+	winner, ok := session.Winner()
+	if !ok || winner.Player != "Valgor" {
+		t.Errorf("winner = %+v, expected Valgor", winner)
+	}
+	if len(List()) != 0 {
+		t.Errorf("the closed session is still listed")
+	}
+	if _, err := Close(0, 333); err == nil {
+		t.Errorf("a closed session was closed again")
+	}
+	if Record("Valgor", 0, 333, 100, opened.Add(time.Minute)) {
+		t.Errorf("a roll was recorded on a closed session")
+	}
+}
+
+func TestRecordSameSecond(t *testing.T) {
+	ResetData()
+	defer ResetData()
+	opened := time.Date(2022, time.February, 16, 20, 0, 0, 0, time.Local)
+	err := Open("Cloak of Flames", 0, 333, opened.Add(500*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	if !Record("Valgor", 0, 333, 200, opened) {
+		t.Errorf("a roll logged in the second the session opened was not recorded")
+	}
+}
+
+func TestWinnerWithoutRolls(t *testing.T) {
+	session := Session{Item: "Cloak of Flames", Low: 0, High: 100}
+	if _, ok := session.Winner(); ok {
+		t.Errorf("a session without rolls has a winner")
+	}
+}
@@ -1,57 +1,111 @@
 package scanner
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Valorith/EQRaidAssist/alias"
 	"github.com/Valorith/EQRaidAssist/config"
 	"github.com/Valorith/EQRaidAssist/core"
-	"github.com/Valorith/EQRaidAssist/discord"
+	"github.com/Valorith/EQRaidAssist/eqlog"
+	"github.com/Valorith/EQRaidAssist/events"
 	"github.com/Valorith/EQRaidAssist/loadFile"
+	"github.com/Valorith/EQRaidAssist/logger"
 	"github.com/Valorith/EQRaidAssist/player"
 	"github.com/Valorith/EQRaidAssist/raid"
+	"github.com/Valorith/EQRaidAssist/roll"
+	"github.com/fsnotify/fsnotify"
 	"github.com/hpcloud/tail"
 )
 
 var (
 	mu                sync.RWMutex
-	isStarted         bool          // Flags the scanner as active or inactive
-	loadedRaidFile    string        // Directory of the raid dump file
 	raidFrequency     time.Duration // Frequency of the raid dump file scan
 	loadedLogFile     string        // Directory of the player's log file
 	raidFrequencyChan chan int
 	stopSignalChan    chan bool
-	serverName        string // Server short name for reference in the log file directory
-	characterName     string // Character name for reference in the log file directory
-	startTime         []int  // Time the scanner was started
-	RebootSavedFile   string // File directory for save file during scanner reboot
+	serverName        string   // Server short name for reference in the log file directory
+	characterName     string   // Character name for reference in the log file directory
+	extraCharacters   []string // Additional characters whose logs are watched alongside characterName
+	startTime         []int    // Time the scanner was started
+	currentZone       string   // Last zone the monitored character was seen entering
+	RebootSavedFile   string   // File directory for save file during scanner reboot
+	logStopChan       chan bool
+	watchedLogs       []*watchedLog // Logs currently being scanned
+	resumeLog         bool          // Resume the log scan from the saved offset rather than the end of the log
+	recovering        bool          // Start keeps the start time restored by RecoverRaid
+	eventMu           sync.Mutex    // Serializes the handling of events coming from several logs
+	lootSightings     []*lootSighting
+	lastParseReport   ParseReport // Outcome of parsing the latest raid dump
+)
+
+// Represents a character log being scanned
+type watchedLog struct {
+	path    string
+	owner   string      // Character writing the log
+	primary bool        // The raid's zone follows the primary character only
+	offset  int64       // Byte offset of the next unprocessed log line (accessed atomically)
+	info    os.FileInfo // Identity of the file being read, used to detect a replaced log
+	head    string      // First line of the file being read
+}
+
+// Represents a loot line and the logs that have reported it
+type lootSighting struct {
+	key     string
+	time    time.Time
+	sources []string
+}
+
+// Matches the creation time in a raid dump file name
+var dumpTimePattern = regexp.MustCompile(`\d{8}-\d{6}`)
+
+const (
+	logOffsetSaveFrequency = time.Minute     // How often the log offset is saved while scanning
+	logHeadLength          = 256             // Length of the log head saved to detect a replaced log
+	zoneSearchLength       = 512 * 1024      // Bytes at the end of the log searched for the current zone
+	lootDuplicateWindow    = 3 * time.Second // Identical loot lines from different logs within this window are merged
+	raidDumpDebounce       = time.Second     // Quiet period after the last write to a raid dump before it is loaded
 )
 
 func ResetData() {
 	mu.Lock()
 	defer mu.Unlock()
-	isStarted = false
-	loadedRaidFile = ""
+	raid.Current.SetScannerStarted(false)
+	raid.Current.SetLoadedRaidFile("")
 	loadedLogFile = ""
 	raidFrequencyChan = nil
 	stopSignalChan = nil
 	serverName = ""
 	characterName = ""
+	extraCharacters = nil
 	startTime = nil
+	currentZone = ""
+	logStopChan = nil
+	watchedLogs = nil
+	resumeLog = false
+	recovering = false
+	lootSightings = nil
+	lastParseReport = ParseReport{}
 }
 
 // Reboot the scanner and save the state
 func Reboot() {
-	if isStarted {
+	if raid.Current.ScannerStarted() {
+		mu.Lock()
 		RebootSavedFile = loadedLogFile
+		resumeLog = true
+		mu.Unlock()
 		core.Rebooting = true
 		Stop()
 		Start()
@@ -60,7 +114,7 @@ func Reboot() {
 
 // Returns true if the file scanner is currently active
 func IsRunning() bool {
-	return isStarted
+	return raid.Current.ScannerStarted()
 }
 
 func SetServerName(name string) error {
@@ -101,6 +155,49 @@ func SetCharacterName(name string) error {
 	return nil
 }
 
+// Adds a character whose log is watched alongside the main character (ie: a boxed client or banker)
+func AddCharacter(name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if name == "" {
+		return fmt.Errorf("AddCharacter(): no character name provided")
+	}
+	if strings.EqualFold(name, characterName) {
+		return fmt.Errorf("AddCharacter(): %s is the main character", name)
+	}
+	for _, extra := range extraCharacters {
+		if strings.EqualFold(extra, name) {
+			return fmt.Errorf("AddCharacter(): %s is already watched", name)
+		}
+	}
+	extraCharacters = append(extraCharacters, name)
+	return nil
+}
+
+// Stops watching the log of an additional character
+func RemoveCharacter(name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	for index, extra := range extraCharacters {
+		if strings.EqualFold(extra, name) {
+			extraCharacters = append(extraCharacters[:index], extraCharacters[index+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("RemoveCharacter(): %s is not watched", name)
+}
+
+// Returns the names of all characters whose logs are watched, main character first
+func GetCharacterNames() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := []string{}
+	if characterName != "" {
+		names = append(names, characterName)
+	}
+	return append(names, extraCharacters...)
+}
+
 func IsCharacterNameSet() bool {
 	mu.RLock()
 	defer mu.RUnlock()
@@ -110,84 +207,187 @@ func IsCharacterNameSet() bool {
 // Starts the scanner, runs until told to stop
 func Start() {
 	var err error
-	if isStarted {
-		fmt.Println("scanner.Start(): scanner is already started")
+	if raid.Current.ScannerStarted() {
+		logger.Warnf(logger.Scanner, "scanner.Start(): scanner is already started")
 		return
 	}
 
 	// Organize Raid Dump Files into subfolder
 	OrganizeRaidDumps()
 
-	isStarted = true
-	if !core.Rebooting {
+	raid.Current.SetScannerStarted(true)
+	mu.Lock()
+	keepStartTime := core.Rebooting || recovering
+	recovering = false
+	mu.Unlock()
+	if !keepStartTime {
 		err = setStartTime()
 		if err != nil {
-			fmt.Printf("scanner.Start(): setStartTime: %s", err)
+			logger.Errorf(logger.Scanner, "scanner.Start(): setStartTime: %s", err)
 		}
 	}
+	mu.Lock()
 	raidFrequency = 10 * time.Second
 	raidFrequencyChan = make(chan int)
 	stopSignalChan = make(chan bool)
+	logStopChan = make(chan bool)
+	mu.Unlock()
 
 	loadSettings() // Load settings from config file
 
 	//Load Players in
 	err = scanRaid()
 	if err != nil {
-		fmt.Println("scanRaid failed:", err)
+		logger.Errorf(logger.Scanner, "scanRaid failed: %v", err)
 	}
 
 	go loop()    // Loop through the scanner loop to detect new raid dump files
-	go scanLog() // Start scanning character log for loot data
+	go scanLog() // Start scanning the character logs for loot data
 	fmt.Println("Listening...")
 }
 
 // Stop the scanner
 func Stop() {
+	raid.Current.SetScannerStarted(false)
+	// In auto mode the raid lasts as long as the scanner, in manual mode it is ended with 'raid end'
+	if config.GetRaidMode() == config.RaidModeAuto {
+		// Announce the end of raid summary, unless the scanner is only rebooting
+		err := endRaid(!core.Rebooting)
+		if err != nil {
+			logger.Warnf(logger.Scanner, "scanner.Stop(): raid.Stop: %v", err)
+		}
+	}
+	// Take the channels without holding mu while signalling, the loop may be waiting on mu in scanRaid
 	mu.Lock()
-	defer mu.Unlock()
-	isStarted = false
-	err := raid.Stop()
-	if err != nil {
-		fmt.Println("scanner.Stop(): raid.Stop:", err)
+	stopChan, logStop, logs := stopSignalChan, logStopChan, watchedLogs
+	stopSignalChan, logStopChan = nil, nil
+	mu.Unlock()
+	if stopChan != nil {
+		close(stopChan)
+	}
+	if logStop != nil {
+		for _, w := range logs {
+			saveLogOffset(w.path, atomic.LoadInt64(&w.offset))
+		}
+		close(logStop)
 	}
-	stopSignalChan <- true
 	if core.Rebooting {
-		fmt.Println("Scanner Rebooting...")
+		logger.Infof(logger.Scanner, "Scanner Rebooting...")
 	} else {
-		fmt.Println("Scanner Shutting Down...")
+		logger.Infof(logger.Scanner, "Scanner Shutting Down...")
 	}
 
 }
 
-// loops for as long as scanner is running (noted by isStarted)
+// loops for as long as scanner is running (noted by raid.Current.ScannerStarted)
+// New raid dumps are picked up from filesystem notifications, the ticker is a fallback
+// for folders where notifications do not arrive (ie: synced or network folders)
 func loop() {
 	mu.RLock()
 	raidTicker := time.NewTicker(raidFrequency)
+	stopChan, frequencyChan := stopSignalChan, raidFrequencyChan
 	mu.RUnlock()
+	defer func() { raidTicker.Stop() }()
+
+	// Watch for raid dumps being written
+	var watchEvents <-chan fsnotify.Event
+	var watchErrors <-chan error
+	watcher, err := newRaidDumpWatcher()
+	if err != nil {
+		logger.Warnf(logger.Scanner, "loop: %s, falling back to the raid file scan timer", err)
+	} else {
+		defer watcher.Close()
+		watchEvents = watcher.Events
+		watchErrors = watcher.Errors
+	}
+	debounce := time.NewTimer(raidDumpDebounce)
+	stopTimer(debounce)
 
 	for {
-		if !isStarted {
+		if !raid.Current.ScannerStarted() {
 			return
 		}
 		select {
-		case <-stopSignalChan:
+		case <-stopChan:
 			return
-		case value := <-raidFrequencyChan:
+		case value := <-frequencyChan:
+			raidTicker.Stop()
 			raidTicker = time.NewTicker(time.Duration(value) * time.Second)
+		case event, ok := <-watchEvents:
+			if !ok {
+				watchEvents = nil
+				continue
+			}
+			if event.Op&(fsnotify.Create|fsnotify.Write) == 0 || !isRaidDumpFile(event.Name) {
+				continue
+			}
+			// Wait for the client to finish writing the dump
+			stopTimer(debounce)
+			debounce.Reset(raidDumpDebounce)
+		case err, ok := <-watchErrors:
+			if !ok {
+				watchErrors = nil
+				continue
+			}
+			logger.Warnf(logger.Scanner, "loop: raid dump watcher: %v", err)
+		case <-debounce.C:
+			err := scanRaid()
+			if err != nil {
+				logger.Errorf(logger.Scanner, "scanRaid failed: %v", err)
+			}
 		case <-raidTicker.C:
 			err := scanRaid()
 			if err != nil {
-				fmt.Println("scanRaid failed:", err)
+				logger.Errorf(logger.Scanner, "scanRaid failed: %v", err)
 				continue
 			}
 		}
 	}
 }
 
+// Returns a watcher notified of changes to the EQ folder, where the client writes raid dumps,
+// and the RaidLogs folder
+func newRaidDumpWatcher() (*fsnotify.Watcher, error) {
+	EQpath, err := config.GetEQDir()
+	if err != nil {
+		return nil, fmt.Errorf("newRaidDumpWatcher(): %w", err)
+	}
+	raidLogsFolder, err := config.GetRaidLogsDir()
+	if err != nil {
+		return nil, fmt.Errorf("newRaidDumpWatcher(): %w", err)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("newRaidDumpWatcher(): fsnotify.NewWatcher: %w", err)
+	}
+	for _, folder := range []string{EQpath, raidLogsFolder} {
+		err = watcher.Add(folder)
+		if err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("newRaidDumpWatcher(): watcher.Add(%s): %w", folder, err)
+		}
+	}
+	return watcher, nil
+}
+
+// Returns true if the path is a raid dump file (ie: RaidRoster_server-20220216-201104.txt)
+func isRaidDumpFile(path string) bool {
+	fileName := filepath.Base(path)
+	return strings.HasPrefix(fileName, "RaidRoster") && strings.HasSuffix(fileName, ".txt")
+}
+
+// Stops the timer and drains its channel so it can be safely reset
+func stopTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
+
 func scanRaid() error {
-	var raidCreated bool = false
-	if !isStarted {
+	if !raid.Current.ScannerStarted() {
 		return fmt.Errorf("scanRaid(): the raid scanner is not running")
 	}
 
@@ -201,6 +401,7 @@ func scanRaid() error {
 	}
 
 	// Check if file was already loaded
+	loadedRaidFile := raid.Current.LoadedRaidFile()
 	if loadedRaidFile == newFileLocation {
 		//fmt.Println("Already operating on the newest Raid Dump...")
 		return nil
@@ -215,146 +416,903 @@ func scanRaid() error {
 		return nil
 	}
 
-	if core.Rebooting {
-		loadedRaidFile = RebootSavedFile
+	// Load the new raid dump file
+	raid.Current.SetLoadedRaidFile(newFileLocation)
+	logger.Infof(logger.Scanner, "Newest Raid Dump File Detected: %v", newFileLocation)
+	mu.RLock()
+	zone, owner := currentZone, characterName
+	mu.RUnlock()
+	report, err := processRaidDump(newFileLocation, owner, zone, config.GetRaidMode() == config.RaidModeAuto)
+	mu.Lock()
+	lastParseReport = report
+	mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("scanRaid: %w", err)
 	}
+	core.Rebooting = false
+	return nil
+}
 
-	// Check for new raid initiation
-	if loadedRaidFile == "" {
-		fmt.Printf("New Raid Initiated (%s)!\n", newFileLocation)
-		raidCreated = true
+// Loads the players of a raid dump file into the players cache and credits them on the active raid.
+// When autoStart is set and no raid is open, the dump starts a new raid in the provided zone.
+// owner is the character that created the dump. Returns the outcome of parsing the dump
+func processRaidDump(dumpFilePath, owner, zone string, autoStart bool) (ParseReport, error) {
+	dumpLines, err := loadFile.Load(dumpFilePath)
+	if err != nil {
+		return ParseReport{}, fmt.Errorf("loadFile.Load %s: %w", dumpFilePath, err)
 	}
 
-	// Load the new raid dump file
-	loadedRaidFile = newFileLocation
-	fmt.Println("Newest Raid Dump File Detected: ", loadedRaidFile)
-	dumpLines, err := loadFile.Load(loadedRaidFile)
-	if err != nil {
-		return fmt.Errorf("loadFile.Load %s: %w", loadedRaidFile, err)
+	// Parse the new raid dump file
+	players, report := parseRaidDump(dumpFilePath, dumpLines)
+	for _, skipped := range report.Skipped {
+		logger.Warnf(logger.Scanner, "Raid dump line %d skipped (%s): %q", skipped.Line, skipped.Reason, skipped.Text)
+	}
+	if len(players) == 0 {
+		return report, fmt.Errorf("no players could be parsed from %s", dumpFilePath)
 	}
 
-	//Clear active players cache
-	core.ClearPlayers()
+	//Clear active players cache, keeping the previous roster to detect changes
+	session := raid.Current
+	previousRoster := session.Players()
+	session.ClearPlayers()
 
-	// Parse the new raid dump file
-	for _, line := range dumpLines {
-		p, err := player.NewFromLine(line)
-		if err != nil {
-			return fmt.Errorf("player.NewFromLine failed (%s): %w", line, err)
-		}
+	for _, p := range players {
 		// Add the player to the players cache
-		core.AddPlayer(p)
-		fmt.Printf("%s added to the players cache\n", p.Name)
-		// Add the player to the raid if needed
-		if !raid.PlayerIsInRaid(p.Name) {
-			raid.AddPlayersToRaid()
-		}
+		session.AddPlayer(p)
+		logger.Debugf(logger.Scanner, "%s added to the players cache", p.Name)
 	}
+	roster := session.Players()
 
 	// Display loaded character cache
-	fmt.Printf("%+v\n", core.Players)
+	logger.Debugf(logger.Scanner, "%+v", roster)
 
 	//Ensure all players are added to the active raid
-	if raid.Active { // Start a check-in
-		raid.AddPlayersToRaid()
-		err = raid.ActiveRaid.CheckIn()
+	created := false
+	switch {
+	case session.IsActive(): // Start a check-in
+		changes := raid.DiffRosters(previousRoster, roster, core.Now())
+		for _, change := range changes {
+			logger.Infof(logger.Scanner, "Roster change: %v", change)
+		}
+		session.RecordRosterChanges(changes)
+		for _, change := range changes {
+			if change.Kind == raid.RosterJoined {
+				session.Publish(events.Event{Kind: events.PlayerJoined, Player: change.Player})
+			} else if change.Kind == raid.RosterLeft {
+				session.Publish(events.Event{Kind: events.PlayerLeft, Player: change.Player})
+			}
+		}
+		session.AddPlayersToRaid()
+		err = session.CheckIn()
+		if err != nil {
+			return report, fmt.Errorf("raid.Current.CheckIn: %w", err)
+		}
+	case autoStart && session.State() == raid.StateIdle: // Start the raid
+		err = session.Start("")
 		if err != nil {
-			return fmt.Errorf("raid.ActiveRaid.CheckIn: %w", err)
+			return report, fmt.Errorf("raid.Current.Start: %w", err)
 		}
-	} else { // Start the raid
-		raid.Start()
-		raid.AddPlayersToRaid()
+		logger.Infof(logger.Scanner, "New Raid Initiated (%s)!", dumpFilePath)
+		session.AddPlayersToRaid()
+		session.EnterZone(zone, core.Now())
+		created = !core.Rebooting // A rebooted scanner picks its raid back up with a check-in
+	default: // Only the players cache is kept up to date
+		logger.Infof(logger.Scanner, "Raid dump loaded while the raid is %s, no check-in recorded", session.State())
+		return report, nil
 	}
 
+	session.UpdateLeaders()
+	rosterNames := []string{}
+	for _, p := range roster {
+		rosterNames = append(rosterNames, p.Name)
+	}
+	session.RecordPresence(rosterNames, core.Now())
+
 	// Update the displayList
-	raid.UpdateDisplayList()
+	session.UpdateDisplayList()
 
-	// Handle discord updates
-	if raidCreated {
-		// Send raid creation message to discord
-		raidCreated = false
-		raidRoster := alias.TryToGetHandle(characterName) + " has started a new raid!\n----------------\n"
-		index := 1
-		for handle := range raid.DisplayList {
-			raidRoster += fmt.Sprintf("%s) %s \n", fmt.Sprint(index), handle)
-			index++
-		}
-		discord.SendEmbedMessage("New Raid Created!", raidRoster, 2)
+	// Publish the raid update, which saves the raid and announces it on discord
+	if created {
+		session.Publish(events.Event{Kind: events.RaidStarted, Player: owner, Text: rosterAnnouncement(owner, true)})
 	} else {
-		// Send raid checkin update to discord
-		raidRoster := alias.TryToGetHandle(characterName) + " has initiated a raid checkin!\n----------------\n"
+		session.Publish(events.Event{Kind: events.CheckIn, Player: owner, Text: rosterAnnouncement(owner, false)})
+	}
+	return report, nil
+}
+
+// Returns the roster posted to discord when owner starts a raid (created) or initiates a check-in
+func rosterAnnouncement(owner string, created bool) string {
+	activeRaid := raid.Current.Raid()
+	displayList := raid.Current.DisplayList()
+	if created {
+		raidRoster := alias.TryToGetHandle(owner) + " has started a new raid!\n" + activeRaid.LeaderSummary() + "----------------\n"
 		index := 1
-		for handle, checkins := range raid.DisplayList {
-			raidRoster += fmt.Sprintf("%s) %s: %d \n", fmt.Sprint(index), handle, checkins)
+		for handle := range displayList {
+			raidRoster += fmt.Sprintf("%s) %s \n", fmt.Sprint(index), handle)
 			index++
 		}
-		discord.SendEmbedMessage("Raid Checkin!", raidRoster, 2)
+		return raidRoster
 	}
-	core.Rebooting = false
+	raidRoster := alias.TryToGetHandle(owner) + " has initiated a raid checkin!\n" + activeRaid.LeaderSummary() + "----------------\n"
+	index := 1
+	for handle, checkins := range displayList {
+		raidRoster += fmt.Sprintf("%s) %s: %d \n", fmt.Sprint(index), handle, checkins)
+		index++
+	}
+	return raidRoster
+}
+
+// Starts recording a raid with the cached players. Without a name, the raid is named after its zones
+func StartRaid(name string) error {
+	session := raid.Current
+	err := session.Start(name)
+	if err != nil {
+		return fmt.Errorf("StartRaid(): %w", err)
+	}
+	mu.RLock()
+	zone := currentZone
+	owner := characterName
+	mu.RUnlock()
+	session.EnterZone(zone, core.Now())
+	session.UpdateLeaders()
+	session.UpdateDisplayList()
+	session.Publish(events.Event{Kind: events.RaidStarted, Player: owner, Text: rosterAnnouncement(owner, true)})
 	return nil
 }
 
-// Scans the character log for loot data
+// Resumes an unfinished raid saved to file (ie: after a crash) and starts the scanner.
+// Raid dumps made since the raid's latest check-in are picked up, and the logs are scanned
+// from their saved offsets so loot awarded while the scanner was down is recorded
+func RecoverRaid(fileName string) error {
+	if IsRunning() {
+		return fmt.Errorf("RecoverRaid(): stop the scanner before recovering a raid")
+	}
+	err := raid.Current.Recover(fileName)
+	if err != nil {
+		return fmt.Errorf("RecoverRaid(): %w", err)
+	}
+	recovered := raid.Current.Raid()
+	resumeFrom := recovered.LastDump.Add(time.Second) // The latest raid dump was already credited
+	if recovered.LastDump.IsZero() {
+		resumeFrom = time.Date(recovered.StartYear, time.Month(recovered.StartMonth), recovered.StartDay, recovered.StartHour, recovered.StartMinute, recovered.StartSecond, 0, time.Local)
+	}
+	mu.Lock()
+	startTime = []int{resumeFrom.Year(), int(resumeFrom.Month()), resumeFrom.Day(), resumeFrom.Hour(), resumeFrom.Minute(), resumeFrom.Second()}
+	resumeLog = true
+	recovering = true
+	mu.Unlock()
+	logger.Infof(logger.Scanner, "Recovered %s (%s), picking up raid dumps from %s", recovered.Name, fileName, resumeFrom.Format("15:04:05"))
+	Start()
+	return nil
+}
+
+// Pauses the active raid, raid dumps and log events are not recorded until it is resumed
+func PauseRaid() error {
+	err := raid.Current.Pause()
+	if err != nil {
+		return fmt.Errorf("PauseRaid(): %w", err)
+	}
+	raid.Current.Publish(events.Event{Kind: events.RaidPaused})
+	return nil
+}
+
+// Resumes recording the paused raid
+func ResumeRaid() error {
+	err := raid.Current.Resume()
+	if err != nil {
+		return fmt.Errorf("ResumeRaid(): %w", err)
+	}
+	// Catch up on a zone change made while paused
+	mu.RLock()
+	zone := currentZone
+	mu.RUnlock()
+	raid.Current.EnterZone(zone, core.Now())
+	raid.Current.Publish(events.Event{Kind: events.RaidResumed})
+	return nil
+}
+
+// Ends the active or paused raid, publishing its summary
+func EndRaid() error {
+	err := endRaid(true)
+	if err != nil {
+		return fmt.Errorf("EndRaid(): %w", err)
+	}
+	return nil
+}
+
+// Ends the raid, the end of raid summary is only announced when announce is set
+func endRaid(announce bool) error {
+	err := raid.Current.Stop()
+	if err != nil {
+		return err
+	}
+	summary := ""
+	if announce {
+		summary = raid.Current.Raid().Summary()
+	}
+	raid.Current.Publish(events.Event{Kind: events.RaidStopped, Text: summary})
+	return nil
+}
+
+// Represents the outcome of parsing a raid dump
+type ParseReport struct {
+	File    string        // Path of the raid dump
+	Time    time.Time     // Time the raid dump was parsed
+	Parsed  int           // Number of players parsed
+	Skipped []SkippedLine // Lines that could not be parsed
+}
+
+// Represents a raid dump line that could not be parsed
+type SkippedLine struct {
+	Line   int    // Line number, starting at 1
+	Text   string // Content of the line
+	Reason string // Why the line was skipped
+}
+
+// Parses the lines of a raid dump into players. Bad lines are skipped and reported
+// rather than failing the whole dump, blank lines, a byte order mark and CRLF line endings are tolerated
+func parseRaidDump(dumpFilePath string, dumpLines []string) ([]*player.Player, ParseReport) {
+	report := ParseReport{File: dumpFilePath, Time: core.Now(), Skipped: []SkippedLine{}}
+	players := []*player.Player{}
+	seen := map[string]bool{}
+	for index, line := range dumpLines {
+		if index == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		p, err := player.NewFromLine(line)
+		if err != nil {
+			report.Skipped = append(report.Skipped, SkippedLine{Line: index + 1, Text: line, Reason: err.Error()})
+			continue
+		}
+		if seen[p.Name] {
+			report.Skipped = append(report.Skipped, SkippedLine{Line: index + 1, Text: line, Reason: "duplicate of an earlier line"})
+			continue
+		}
+		seen[p.Name] = true
+		players = append(players, p)
+	}
+	report.Parsed = len(players)
+	return players, report
+}
+
+// Returns the report of the latest raid dump parsed, false if no raid dump has been parsed yet
+func GetParseReport() (ParseReport, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if lastParseReport.File == "" {
+		return ParseReport{}, false
+	}
+	return lastParseReport, true
+}
+
+// Scans the logs of the watched characters for loot data
 func scanLog() {
-	fmt.Println("Log Scanner Booting Up...")
-	// Establish the log filepath
-	logFilePath, err := getLogDirectory()
+	logger.Infof(logger.Scanner, "Log Scanner Booting Up...")
+	// Establish the log filepaths
+	logFilePaths, err := getLogDirectories()
 	if err != nil {
-		fmt.Printf("getLogDirectory: %s", err)
-		isStarted = false
+		logger.Errorf(logger.Scanner, "getLogDirectories: %s", err)
+		raid.Current.SetScannerStarted(false)
 		return
 	}
+	mu.Lock()
+	resume := resumeLog
+	resumeLog = false
+	stopChan := logStopChan
+	owners := append([]string{characterName}, extraCharacters...)
+	watchedLogs = []*watchedLog{}
+	for index, logFilePath := range logFilePaths {
+		watchedLogs = append(watchedLogs, &watchedLog{path: logFilePath, owner: owners[index], primary: index == 0})
+	}
+	logs := watchedLogs
+	mu.Unlock()
+
+	for _, w := range logs {
+		go tailLog(w, resume, stopChan)
+	}
+}
+
+// Tails a single character log, dispatching its events until the scanner stops
+func tailLog(w *watchedLog, resume bool, stopChan chan bool) {
+	// Establish where to start reading the log
+	startOffset, err := getStartOffset(w.path, resume, w.primary)
+	if err != nil {
+		logger.Warnf(logger.Scanner, "scanLog: getStartOffset: %s", err)
+	}
+	atomic.StoreInt64(&w.offset, startOffset)
+	w.info, w.head = logIdentity(w.path)
+
 	// Monitor the character log file for loot messagess
-	t, err := tail.TailFile(logFilePath, tail.Config{Follow: true})
+	location := &tail.SeekInfo{Offset: startOffset, Whence: io.SeekStart}
+	t, err := tail.TailFile(w.path, tail.Config{Follow: true, ReOpen: true, Location: location})
 	if err != nil {
-		fmt.Printf("tail.TailFile: %s", err)
+		logger.Errorf(logger.Scanner, "tail.TailFile: %s", err)
+		return
 	}
+	defer t.Stop()
+	logger.Infof(logger.Scanner, "Watching %s...", w.path)
 
-	for line := range t.Lines {
-		if !isStarted {
-			fmt.Printf("scanLog: t.lines: exited log scan due to scanner being disabled")
+	saveTicker := time.NewTicker(logOffsetSaveFrequency)
+	defer saveTicker.Stop()
+	pendingRoller := "" // Player whose /random result is expected on the next roll result line
+	for {
+		select {
+		case <-stopChan:
+			logger.Infof(logger.Scanner, "scanLog: exited scan of %s due to scanner being disabled", w.owner)
 			return
+		case <-saveTicker.C:
+			checkLogReplaced(t, w)
+			saveLogOffset(w.path, atomic.LoadInt64(&w.offset))
+		case line, ok := <-t.Lines:
+			if !ok {
+				logger.Warnf(logger.Scanner, "scanLog: t.lines: tail of %s stopped: %v", w.owner, t.Err())
+				return
+			}
+			atomic.AddInt64(&w.offset, int64(len(line.Text))+1) // Text excludes the newline
+			eventMu.Lock()
+			pendingRoller = handleLogLine(w.owner, w.primary, line.Text, pendingRoller)
+			eventMu.Unlock()
 		}
-		lineText := line.Text
+	}
+}
 
-		if isLootStatement(lineText) { // Filter out non loot statements
-			// Ensure the line occured after the start time
-			lineRecent, err := checkRecent(lineText)
+// Replays a historical log along with the raid dumps in RaidLogs created between from and to,
+// rebuilding the raid on a simulated clock and saving it to SavedRaids
+func Replay(logFileName string, from, to time.Time) error {
+	if IsRunning() || raid.Current.State() != raid.StateIdle {
+		return fmt.Errorf("Replay(): stop the scanner before replaying a raid")
+	}
+	if !to.After(from) {
+		return fmt.Errorf("Replay(): the end of the replay must be after its start")
+	}
+
+	// Locate the log and the raid dumps
+	logFilePath := logFileName
+	if filepath.Base(logFileName) == logFileName {
+		logsFolder, err := config.GetLogsDir()
+		if err != nil {
+			return fmt.Errorf("Replay(): %w", err)
+		}
+		logFilePath = filepath.Join(logsFolder, logFileName)
+	}
+	owner := getLogOwner(logFilePath)
+	if owner == "" {
+		return fmt.Errorf("Replay(): %s is not a character log (eqlog_<character>_<server>.txt)", logFileName)
+	}
+	dumps, err := getRaidDumpsBetween(from, to)
+	if err != nil {
+		return fmt.Errorf("Replay(): %w", err)
+	}
+	if len(dumps) == 0 {
+		return fmt.Errorf("Replay(): no raid dumps found between %s and %s", from, to)
+	}
+	logFile, err := os.Open(logFilePath)
+	if err != nil {
+		return fmt.Errorf("Replay(): os.Open: %w", err)
+	}
+	defer logFile.Close()
+
+	// Run the replay on a simulated clock, without outside notifications
+	var replayTime time.Time
+	core.Replaying = true
+	core.SetClock(func() time.Time { return replayTime })
+	defer func() {
+		core.SetClock(nil)
+		core.Replaying = false
+		raid.Current.Reset()
+	}()
+	fmt.Printf("Replaying %s with %d raid dumps...\n", logFilePath, len(dumps))
+
+	// Merge the log lines and raid dumps in time order. The replayed character's zone is
+	// followed from the start of the replay, apart from the live scanner's
+	nextDump := 0
+	zone := ""
+	replayDumpsUntil := func(until time.Time) error {
+		for nextDump < len(dumps) && !dumps[nextDump].time.After(until) {
+			replayTime = dumps[nextDump].time
+			_, err := processRaidDump(dumps[nextDump].path, owner, zone, true)
 			if err != nil {
-				fmt.Printf("scanLog: lineIsRecent: %s", err)
-			}
-			if !lineRecent {
-				continue
+				return fmt.Errorf("processRaidDump: %w", err)
 			}
+			nextDump++
+		}
+		return nil
+	}
+	pendingRoller := ""
+	lineScanner := bufio.NewScanner(logFile)
+	for lineScanner.Scan() {
+		event, err := eqlog.Parse(lineScanner.Text())
+		if err != nil || event.Time.Before(from) {
+			continue
+		}
+		if event.Time.After(to) {
+			break
+		}
+		err = replayDumpsUntil(event.Time)
+		if err != nil {
+			return fmt.Errorf("Replay(): %w", err)
+		}
+		replayTime = event.Time
+		if event.Kind == eqlog.KindZone {
+			zone = event.Field("zone")
+			enterZone(zone, event.Time)
+			continue
+		}
+		if !raid.Current.IsActive() { // Nothing else to record until the first raid dump
+			continue
+		}
+		pendingRoller = handleEvent(owner, false, event, pendingRoller)
+	}
+	if err := lineScanner.Err(); err != nil {
+		return fmt.Errorf("Replay(): reading log: %w", err)
+	}
+	err = replayDumpsUntil(to)
+	if err != nil {
+		return fmt.Errorf("Replay(): %w", err)
+	}
+
+	// Save the rebuilt raid
+	err = raid.Current.StopOffline()
+	if err != nil {
+		return fmt.Errorf("Replay(): raid.StopOffline: %w", err)
+	}
+	raid.Current.Publish(events.Event{Kind: events.RaidStopped})
+	events.Wait()
+	replayedRaid := raid.Current.Raid()
+	fmt.Printf("Replay complete: %s saved to %s\n", replayedRaid.Name, replayedRaid.FileName)
+	return nil
+}
 
-			charName, itemName, lootType, err := parseLootLine(lineText)
+// Represents a raid dump file and the time it was created
+type raidDump struct {
+	path string
+	time time.Time
+}
+
+// Returns the raid dumps in the RaidLogs folder created between from and to, oldest first
+func getRaidDumpsBetween(from, to time.Time) ([]raidDump, error) {
+	raidLogsFolder, err := config.GetRaidLogsDir()
+	if err != nil {
+		return nil, fmt.Errorf("getRaidDumpsBetween(): %w", err)
+	}
+	raidDumpFileList, err := getRaidDumpFiles(raidLogsFolder)
+	if err != nil {
+		return nil, fmt.Errorf("getRaidDumpsBetween(): %w", err)
+	}
+	dumps := []raidDump{}
+	for _, fileName := range raidDumpFileList {
+		dumpPath := filepath.Join(raidLogsFolder, fileName)
+		dumpTime, err := getDumpTime(dumpPath)
+		if err != nil {
+			logger.Warnf(logger.Scanner, "getRaidDumpsBetween(): %s", err)
+			continue
+		}
+		if dumpTime.Before(from) || dumpTime.After(to) {
+			continue
+		}
+		dumps = append(dumps, raidDump{path: dumpPath, time: dumpTime})
+	}
+	sort.Slice(dumps, func(i, j int) bool { return dumps[i].time.Before(dumps[j].time) })
+	return dumps, nil
+}
+
+// Returns the time a raid dump was created, taken from its file name
+// (ie: RaidRoster_server-20220216-201104.txt) or its modification time
+func getDumpTime(dumpFilePath string) (time.Time, error) {
+	if match := dumpTimePattern.FindString(filepath.Base(dumpFilePath)); match != "" {
+		dumpTime, err := time.ParseInLocation("20060102-150405", match, time.Local)
+		if err == nil {
+			return dumpTime, nil
+		}
+	}
+	modTime, err := loadFile.GetFileLastWrite(dumpFilePath)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("getDumpTime(%s): %w", dumpFilePath, err)
+	}
+	return modTime, nil
+}
+
+// Returns the character name of a log file path (eqlog_<character>_<server>.txt)
+func getLogOwner(logFilePath string) string {
+	fileName := filepath.Base(logFilePath)
+	if !strings.HasPrefix(fileName, "eqlog_") {
+		return ""
+	}
+	parts := strings.Split(strings.TrimSuffix(fileName, ".txt"), "_")
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[1]
+}
+
+// Parses a log line and dispatches the resulting event. owner is the character writing the log,
+// primary is set for the main character's log. Returns the player whose /random result is expected on the next line
+func handleLogLine(owner string, primary bool, lineText, pendingRoller string) string {
+	event, err := eqlog.Parse(lineText)
+	if err != nil {
+		logger.Debugf(logger.Scanner, "scanLog: eqlog.Parse: %s", err)
+		return pendingRoller
+	}
+	return handleEvent(owner, primary, event, pendingRoller)
+}
+
+// Dispatches a parsed log event, zone changes are only followed for the primary log
+func handleEvent(owner string, primary bool, event eqlog.Event, pendingRoller string) string {
+	switch event.Kind {
+	case eqlog.KindLoot:
+		handleLoot(owner, event)
+	case eqlog.KindSlain:
+		handleSlain(owner, event)
+	case eqlog.KindDeath:
+		handleDeath(owner, event)
+	case eqlog.KindZone:
+		if primary { // Boxed characters and bankers are often parked in other zones
+			handleZone(event)
+		}
+	case eqlog.KindChat:
+		handleChat(owner, event)
+	case eqlog.KindRoll:
+		return event.Field("player")
+	case eqlog.KindRollResult:
+		handleRollResult(pendingRoller, event)
+		return ""
+	}
+	return pendingRoller
+}
+
+// Returns the offset the log scan should start from. A resumed scan continues from the saved offset,
+// unless the log has since been truncated or replaced. A new scan starts at the end of the log,
+// recovering the current zone from the log if it is the primary log
+func getStartOffset(logFilePath string, resume, primary bool) (int64, error) {
+	fileInfo, err := os.Stat(logFilePath)
+	if err != nil {
+		return 0, fmt.Errorf("getStartOffset(): os.Stat: %w", err)
+	}
+	if resume {
+		saved, ok := config.GetLogOffset(filepath.Base(logFilePath))
+		if ok {
+			head, err := readLogHead(logFilePath)
 			if err != nil {
-				fmt.Printf("scanLog: parseLootLine: %s", err)
+				return 0, fmt.Errorf("getStartOffset(): %w", err)
 			}
-
-			if lootType != "the Loot Council" { // Filter out all non loot council assignments
-				continue
+			if saved.Offset > fileInfo.Size() || head != saved.Head {
+				logger.Warnf(logger.Scanner, "%s was truncated or replaced, scanning from the start...", logFilePath)
+				return 0, nil
 			}
+			logger.Infof(logger.Scanner, "Resuming log scan at byte %d...", saved.Offset)
+			return saved.Offset, nil
+		}
+	}
 
-			lootMessage := charName + " has received " + itemName + " from " + lootType
-			fmt.Println(lootMessage)
-
-			// Send discord message via WebHook
-			discord.SendMessage(lootMessage, 1)
-
-			// Assign loot to specific cached player
-			for _, p := range core.Players {
-				if p.Name == charName {
-					err = p.AddLoot(player.LootItem{Name: itemName, Count: 1, Description: ""})
-					if err != nil {
-						fmt.Printf("scanLog: player.AddLoot: %s", err)
-					}
-					break
-				}
-			}
-			raid.ActiveRaid.SaveToFile()
+	if !primary {
+		return fileInfo.Size(), nil
+	}
+
+	// Skipping the history of the log, so find out which zone the character is in
+	zone, err := findLastZone(logFilePath, fileInfo.Size())
+	if err != nil {
+		logger.Warnf(logger.Scanner, "getStartOffset(): findLastZone: %s", err)
+	}
+	if zone != "" {
+		mu.Lock()
+		currentZone = zone
+		mu.Unlock()
+	}
+	return fileInfo.Size(), nil
+}
+
+// Returns the first line of the log (up to logHeadLength bytes)
+func readLogHead(logFilePath string) (string, error) {
+	file, err := os.Open(logFilePath)
+	if err != nil {
+		return "", fmt.Errorf("readLogHead(): os.Open: %w", err)
+	}
+	defer file.Close()
+	buffer := make([]byte, logHeadLength)
+	count, err := file.Read(buffer)
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("readLogHead(): file.Read: %w", err)
+	}
+	head := string(buffer[:count])
+	if index := strings.Index(head, "\n"); index >= 0 {
+		head = head[:index]
+	}
+	return head, nil
+}
+
+// Returns the last zone entered within the final zoneSearchLength bytes of the log
+func findLastZone(logFilePath string, size int64) (string, error) {
+	file, err := os.Open(logFilePath)
+	if err != nil {
+		return "", fmt.Errorf("findLastZone(): os.Open: %w", err)
+	}
+	defer file.Close()
+	start := size - zoneSearchLength
+	if start < 0 {
+		start = 0
+	}
+	buffer := make([]byte, size-start)
+	_, err = file.ReadAt(buffer, start)
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("findLastZone(): file.ReadAt: %w", err)
+	}
+	zone := ""
+	for _, line := range strings.Split(string(buffer), "\n") {
+		event, err := eqlog.Parse(line)
+		if err == nil && event.Kind == eqlog.KindZone {
+			zone = event.Field("zone")
+		}
+	}
+	return zone, nil
+}
+
+// Resynchronizes the processed offset when the log was truncated or replaced underneath the scanner
+// (ie: the log was archived and a new one started), as the tail reopens it from the start
+func checkLogReplaced(t *tail.Tail, w *watchedLog) {
+	if !logReplaced(w) {
+		return
+	}
+	offset, err := t.Tell()
+	if err != nil {
+		offset = 0
+	}
+	logger.Warnf(logger.Scanner, "%s was truncated or replaced, continuing at byte %d...", w.path, offset)
+	atomic.StoreInt64(&w.offset, offset)
+	w.info, w.head = logIdentity(w.path)
+}
+
+// Returns true if the log is shorter than the processed offset, or is no longer the file being read.
+// A replaced log may already have grown past the offset, so its identity and first line are compared too
+func logReplaced(w *watchedLog) bool {
+	info, head := logIdentity(w.path)
+	if info == nil {
+		return false
+	}
+	if info.Size() < atomic.LoadInt64(&w.offset) {
+		return true
+	}
+	if w.info != nil && !os.SameFile(w.info, info) {
+		return true
+	}
+	return w.head != "" && head != w.head
+}
+
+// Returns the file info and first line of the log, or a nil file info if it cannot be read
+func logIdentity(logFilePath string) (os.FileInfo, string) {
+	info, err := os.Stat(logFilePath)
+	if err != nil {
+		return nil, ""
+	}
+	head, err := readLogHead(logFilePath)
+	if err != nil {
+		return nil, ""
+	}
+	return info, head
+}
+
+// Persists the processed offset of the log so the scan can be resumed
+func saveLogOffset(logFilePath string, offset int64) {
+	if logFilePath == "" {
+		return
+	}
+	head, err := readLogHead(logFilePath)
+	if err != nil {
+		logger.Errorf(logger.Scanner, "saveLogOffset: %s", err)
+		return
+	}
+	err = config.SetLogOffset(filepath.Base(logFilePath), config.LogOffset{Offset: offset, Head: head})
+	if err != nil {
+		logger.Errorf(logger.Scanner, "saveLogOffset: %s", err)
+	}
+}
+
+// Records a loot distribution event against the cached player, while a raid is being recorded
+func handleLoot(owner string, event eqlog.Event) {
+	if raid.Current.State() != raid.StateActive {
+		return
+	}
+	charName := event.Field("player")
+	itemName := event.Field("item")
+	method := event.Field("method")
+	if charName == "" { // Items looted by the log owner are credited to them
+		charName = owner
+		if method == eqlog.MethodMasterLooting {
+			// Every watched log reports the master looter's loot, so it is credited to the master looter
+			// rather than the log owner. Until the master looter is known, the item is unassigned
+			charName = raid.Current.MasterLooter()
 		}
 	}
+	if isDuplicateLoot(owner, charName, event) {
+		return
+	}
+	policy := config.GetLootPolicy(method)
+
+	lootMessage := charName + " has received " + itemName + " from " + eqlog.MethodDescription(method)
+	if corpse := event.Field("corpse"); corpse != "" {
+		lootMessage = charName + " has looted " + itemName + " from " + corpse + "'s corpse"
+	} else if charName == "" {
+		lootMessage = itemName + " has been looted by an unknown Master Looter"
+	}
+	logger.Infof(logger.Scanner, "%v", lootMessage)
+
+	// Assign loot to specific cached player
+	lootItem := player.LootItem{
+		Name:          itemName,
+		Count:         1,
+		Description:   "",
+		Method:        method,
+		Corpse:        event.Field("corpse"),
+		Time:          event.Time,
+		Source:        owner,
+		CountsAgainst: policy.Count}
+	encounterWindow := config.GetEncounterWindow()
+	lootItem.Encounter = raid.Current.EncounterAt(event.Time, encounterWindow)
+	added := false
+	if charName == "" {
+		added = raid.Current.AddUnassigned(lootItem)
+	} else {
+		var err error
+		added, err = raid.Current.AddLoot(charName, lootItem)
+		if err != nil {
+			logger.Warnf(logger.Scanner, "scanLog: %s, %s was not recorded", err, itemName)
+		}
+	}
+	// Only link new items, log lines read again (ie: after a restart) are already linked
+	if added {
+		raid.Current.LinkLoot(charName, itemName, event.Time, encounterWindow)
+	}
+	raid.Current.Publish(events.Event{Kind: events.LootAwarded, Player: charName, Text: lootMessage, Item: &lootItem})
+}
+
+// Returns true if another watched log already reported the same loot line.
+// Each sighting absorbs at most one line per log, so repeated drops of an item are all kept.
+// A replay reads a single log, so it leaves the live scanner's sightings alone
+func isDuplicateLoot(owner, charName string, event eqlog.Event) bool {
+	if core.Replaying {
+		return false
+	}
+	key := strings.ToLower(strings.Join([]string{event.Field("method"), charName, event.Field("item"), event.Field("corpse")}, "|"))
+
+	// Forget sightings that fell out of the window
+	recent := lootSightings[:0]
+	for _, sighting := range lootSightings {
+		if event.Time.Sub(sighting.time) <= lootDuplicateWindow {
+			recent = append(recent, sighting)
+		}
+	}
+	lootSightings = recent
+
+	for _, sighting := range lootSightings {
+		if sighting.key != key || sliceContains(sighting.sources, owner) {
+			continue
+		}
+		if sighting.time.Sub(event.Time) > lootDuplicateWindow {
+			continue
+		}
+		sighting.sources = append(sighting.sources, owner)
+		logger.Debugf(logger.Scanner, "Duplicate loot line from %s's log ignored: %s", owner, event.Message)
+		return true
+	}
+	lootSightings = append(lootSightings, &lootSighting{key: key, time: event.Time, sources: []string{owner}})
+	return false
+}
+
+// Returns true if the slice contains the value
+func sliceContains(slice []string, value string) bool {
+	for _, item := range slice {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Records the kill of a configured boss as an encounter on the active raid
+// Slain raid members are recorded as deaths
+func handleSlain(owner string, event eqlog.Event) {
+	boss := event.Field("target")
+	if !raid.Current.IsActive() {
+		return
+	}
+	killer := event.Field("killer")
+	if killer == "" {
+		killer = owner
+	}
+	if !config.IsBoss(boss) {
+		if raid.Current.IsRaidMember(boss) {
+			recordDeath(boss, killer, event.Time)
+		}
+		return
+	}
+	if !raid.Current.RecordEncounter(boss, killer, event.Time, config.GetEncounterWindow()) {
+		return
+	}
+	logger.Infof(logger.Scanner, "Encounter recorded: %s has been slain by %s!", boss, killer)
+	raid.Current.Publish(events.Event{Kind: events.RaidUpdated})
+}
+
+// Records the death of a raid member
+func handleDeath(owner string, event eqlog.Event) {
+	if !raid.Current.IsActive() {
+		return
+	}
+	charName := event.Field("player")
+	if charName == "" {
+		charName = owner
+	}
+	if !raid.Current.IsRaidMember(charName) {
+		return
+	}
+	recordDeath(charName, event.Field("killer"), event.Time)
+}
+
+func recordDeath(charName, killer string, deathTime time.Time) {
+	if !raid.Current.RecordDeath(charName, killer, deathTime) {
+		return
+	}
+	logger.Infof(logger.Scanner, "Death recorded: %s", charName)
+	raid.Current.Publish(events.Event{Kind: events.RaidUpdated})
+}
+
+// Follows the primary character's zone and records the zone change on the active raid
+func handleZone(event eqlog.Event) {
+	zone := event.Field("zone")
+	mu.Lock()
+	currentZone = zone
+	mu.Unlock()
+	enterZone(zone, event.Time)
+}
+
+// Records a zone change on the active raid
+func enterZone(zone string, enterTime time.Time) {
+	if !raid.Current.IsActive() {
+		return
+	}
+	logger.Infof(logger.Scanner, "Zone change detected: %s", zone)
+	raid.Current.EnterZone(zone, enterTime)
+	raid.Current.Publish(events.Event{Kind: events.RaidUpdated})
+}
+
+// Checks in raiders who type the check-in keyword in guild chat or raid say
+func handleChat(owner string, event eqlog.Event) {
+	if !raid.Current.IsActive() {
+		return
+	}
+	keyword, err := config.GetCheckinKeyword()
+	if err != nil { // Chat check-ins are disabled
+		return
+	}
+	if !strings.EqualFold(strings.TrimSpace(event.Field("text")), keyword) {
+		return
+	}
+	charName := event.Field("player")
+	if charName == "" {
+		charName = owner
+	}
+	if raid.Current.ChatCheckIn(charName, event.Time) {
+		logger.Infof(logger.Scanner, "%s checked in through %s chat", charName, event.Field("channel"))
+	}
+}
+
+// Records a /random result against the open roll session on its range
+func handleRollResult(roller string, event eqlog.Event) {
+	if roller == "" {
+		return
+	}
+	low, errLow := strconv.Atoi(event.Field("low"))
+	high, errHigh := strconv.Atoi(event.Field("high"))
+	value, errValue := strconv.Atoi(event.Field("value"))
+	if errLow != nil || errHigh != nil || errValue != nil {
+		logger.Warnf(logger.Scanner, "scanLog: invalid roll result: %s", event.Message)
+		return
+	}
+	if roll.Record(roller, low, high, value, event.Time) {
+		logger.Infof(logger.Scanner, "%s rolled %d (%s)", roller, value, roll.Key(low, high))
+	}
 }
 
 func loadSettings() error {
@@ -434,39 +1392,6 @@ func convertMonthToInt(monthString string) (int, error) {
 	return currentMonthInt, nil
 }
 
-// Detirmines if the line was created after the scanner started
-func checkRecent(line string) (bool, error) {
-	if !(len(startTime) == 6) {
-		return false, fmt.Errorf("checkRecent: startTime is not set. length=%d", len(startTime))
-	}
-	startYear, startMonth, startDay, startHour, startMinute, startSecond := startTime[0], startTime[1], startTime[2], startTime[3], startTime[4], startTime[5]
-	lineYear, lineMonth, lineDay, lineHour, lineMinute, lineSecond, err := parseDateTime(line)
-	if err != nil {
-		return false, fmt.Errorf("lineIsRecent: parseDateTime: %w", err)
-	}
-
-	//Check if startTime is after the lineTime
-	if startYear > lineYear {
-		return false, nil
-	}
-	if startYear == lineYear && startMonth > lineMonth {
-		return false, nil
-	}
-	if startYear == lineYear && startMonth == lineMonth && startDay > lineDay {
-		return false, nil
-	}
-	if startYear == lineYear && startMonth == lineMonth && startDay == lineDay && startHour > lineHour {
-		return false, nil
-	}
-	if startYear == lineYear && startMonth == lineMonth && startDay == lineDay && startHour == lineHour && startMinute > lineMinute {
-		return false, nil
-	}
-	if startYear == lineYear && startMonth == lineMonth && startDay == lineDay && startHour == lineHour && startMinute == lineMinute && startSecond > lineSecond {
-		return false, nil
-	}
-	return true, nil
-}
-
 // Detirmines if the file was created after the scanner started
 func checkFileRecent(fileTime string) (bool, error) {
 
@@ -498,76 +1423,6 @@ func checkFileRecent(fileTime string) (bool, error) {
 	return true, nil
 }
 
-// Returns whether fileTime (first argument) is newer than oldFileTime (second argument)
-func fileIsNewer(oldFileTime string, fileTime string) (bool, error) {
-
-	oldYear, oldMonth, oldDay, oldHour, oldMinute, oldSecond, err := parseFileDateTime(oldFileTime)
-	if err != nil {
-		return false, fmt.Errorf("fileIsNewer: parseDateTime1: %w", err)
-	}
-	fileYear, fileMonth, fileDay, fileHour, fileMinute, fileSecond, err := parseFileDateTime(fileTime)
-	if err != nil {
-		return false, fmt.Errorf("fileIsNewer: parseDateTime2: %w", err)
-	}
-
-	//Check if startTime is after the lineTime
-	if oldYear > fileYear {
-		return false, nil
-	}
-	if oldYear == fileYear && oldMonth > fileMonth {
-		return false, nil
-	}
-	if oldYear == fileYear && oldMonth == fileMonth && oldDay > fileDay {
-		return false, nil
-	}
-	if oldYear == fileYear && oldMonth == fileMonth && oldDay == fileDay && oldHour > fileHour {
-		return false, nil
-	}
-	if oldYear == fileYear && oldMonth == fileMonth && oldDay == fileDay && oldHour == fileHour && oldMinute > fileMinute {
-		return false, nil
-	}
-	if oldYear == fileYear && oldMonth == fileMonth && oldDay == fileDay && oldHour == fileHour && oldMinute == fileMinute && oldSecond > fileSecond {
-		return false, nil
-	}
-	return true, nil
-}
-
-// Returns the deconstructed date and time values for the provided log dateTime string
-func parseDateTime(line string) (int, int, int, int, int, int, error) {
-	dateElements := strings.Split(line, " ")
-	monthString := dateElements[1]
-	dayString := dateElements[2]
-	timeString := dateElements[3]
-	yearString := dateElements[4][:len(dateElements[4])-1]
-
-	day, err := strconv.Atoi(dayString)
-	if err != nil {
-		return 0, 0, 0, 0, 0, 0, fmt.Errorf("parseDateTime: strconv.Atoi: %w", err)
-	}
-	year, err := strconv.Atoi(yearString)
-	if err != nil {
-		return 0, 0, 0, 0, 0, 0, fmt.Errorf("parseDateTime: strconv.Atoi: %w", err)
-	}
-	hour, err := strconv.Atoi(timeString[:strings.Index(timeString, ":")])
-	if err != nil {
-		return 0, 0, 0, 0, 0, 0, fmt.Errorf("parseDateTime: strconv.Atoi: %w", err)
-	}
-	minute, err := strconv.Atoi(timeString[strings.Index(timeString, ":")+1 : strings.LastIndex(timeString, ":")])
-	if err != nil {
-		return 0, 0, 0, 0, 0, 0, fmt.Errorf("parseDateTime: strconv.Atoi: %w", err)
-	}
-	second, err := strconv.Atoi(timeString[strings.LastIndex(timeString, ":")+1:])
-	if err != nil {
-		return 0, 0, 0, 0, 0, 0, fmt.Errorf("parseDateTime: strconv.Atoi: %w", err)
-	}
-
-	month, err := convertMonthToInt(monthString)
-	if err != nil {
-		return 0, 0, 0, 0, 0, 0, fmt.Errorf("parseDateTime: convertMonthToInt: %w", err)
-	}
-	return year, month, day, hour, minute, second, nil
-}
-
 // Returns the deconstructed date and time values for the provided file dateTime string
 func parseFileDateTime(dateTime string) (int, int, int, int, int, int, error) {
 	if dateTime == "" {
@@ -616,114 +1471,50 @@ func parseFileDateTime(dateTime string) (int, int, int, int, int, int, error) {
 	return year, month, day, hour, minute, second, nil
 }
 
-func isLootStatement(line string) bool {
-	if strings.Contains(line, "has been awarded to") || strings.Contains(line, "has been looted by the Master") {
-		return true
-	}
-	return false
-}
-
-func parseLootLine(line string) (string, string, string, error) {
-	line = line[strings.Index(line, "]")+2:]
-	itemName, playerName := "", ""
-	if strings.Contains(line, "has been awarded to") {
-		// Get the item name
-		itemName = strings.TrimSpace(strings.Split(line, "has been awarded to")[0])
-		// Get the player name
-		playerName = strings.TrimSpace(strings.Split(line, "has been awarded to")[1])
-		playerName = playerName[:strings.Index(playerName, " ")]
-	} else if strings.Contains(line, "has been looted by the Master") {
-		// Get the item name
-		itemName = line[:strings.Index(line, "has been looted by the Master")-1]
-		// Get the player name
-		playerName = "Master Looter"
-	}
-
-	// Get loot distribution type
-	masterLooterTake := "looted by the Master Loot."
-	masterLooterTake = strings.ToLower(masterLooterTake)
-	assignedTo := "by the Master Looter."
-	assignedTo = strings.ToLower(assignedTo)
-	randomTo := "by random roll."
-	randomTo = strings.ToLower(randomTo)
-	lcAward := "by the Loot Council."
-	lcAward = strings.ToLower(lcAward)
-	lootType := ""
-	line = strings.ToLower(line)
-
-	if strings.Contains(line, masterLooterTake) { // Matches if the master looter took the loot
-		lootType = "master looting."
-	} else if strings.Contains(line, assignedTo) { // Matches if the loot was assigned by the master looter
-		lootType = "loot master assignment."
-	} else if strings.Contains(line, randomTo) { // Matches if the loot was assigned by random roll
-		lootType = "random assignment."
-	} else if strings.Contains(line, lcAward) { // Matches if the loot was assigned by the loot council
-		lootType = "the Loot Council"
-	} else {
-		fmt.Printf("Line: %s, compared with %s\n", line, masterLooterTake)
-	}
-
-	return playerName, itemName, lootType, nil
-}
-
-func getLogDirectory() (string, error) {
+// Returns the log file paths of the watched characters, main character first
+func getLogDirectories() ([]string, error) {
 	// Get the directory of the current executable
 	mu.Lock()
 	defer mu.Unlock()
-	EQpath, err := os.Getwd() // Get the current working directory (used as EQpath)
+	logsFolder, err := config.GetLogsDir()
 	if err != nil {
-		return "", fmt.Errorf("scanLog: os.Getwd: %w", err)
+		return nil, fmt.Errorf("getLogDirectories(): %w", err)
+	}
+	logFilePaths := []string{}
+	for _, name := range append([]string{characterName}, extraCharacters...) {
+		logFilePaths = append(logFilePaths, filepath.Join(logsFolder, "eqlog_"+name+"_"+serverName+".txt"))
 	}
-	loadedLogFile = EQpath + "\\Logs\\eqlog_" + characterName + "_" + serverName + ".txt"
-	return loadedLogFile, nil
+	loadedLogFile = logFilePaths[0]
+	return logFilePaths, nil
 }
 
 // Return the directory to the newest detected Raid Dump file
 func getNewestRaidFile() (string, string, error) {
-	// Get Root EQ Directory
-	EQpath, err := os.Getwd()
+	// Get the directory of the RaidLogs folder
+	raidLogsFolder, err := config.GetRaidLogsDir()
 	if err != nil {
-		log.Println(err)
-		return "", "", fmt.Errorf("getwd: %w", err)
+		return "", "", fmt.Errorf("getNewestRaidFile(): %w", err)
 	}
 
-	// Get the directory of the RaidLogs folder
-	raidLogsFolder := EQpath + "\\RaidLogs"
-
-	// Load a list of all Raid dump files
-	raidDumpFileList, err := getRaidDumpFiles(raidLogsFolder)
+	// Detirmine which Raid dump file is the newest, using the file info from the directory listing
+	raidDumpFileInfo, err := ioutil.ReadDir(raidLogsFolder)
 	if err != nil {
 		return "", "", fmt.Errorf("getNewestRaidFile(): %w", err)
 	}
-
-	// Detirmine which file in raidDumpFileList is the newest
-	newestIndex := 0
-	newestModified := ""
-	newestPath := ""
-	for index, raidFilePath := range raidDumpFileList {
-		oldFileModDate, err := getFileModDate(raidDumpFileList[newestIndex])
-		if err != nil {
-			return "", "", fmt.Errorf("getFileModDate: %w", err)
-		}
-		newFileModDate, err := getFileModDate(raidFilePath)
-		if err != nil {
-			return "", "", fmt.Errorf("getFileModDate: %w", err)
-		}
-		fileNewer, err := fileIsNewer(oldFileModDate, newFileModDate)
-		if err != nil {
-			return "", "", fmt.Errorf("getNewestRaidFile(): fileIsNewer: %w", err)
+	var newest os.FileInfo
+	for _, file := range raidDumpFileInfo {
+		if !strings.Contains(file.Name(), "RaidRoster") {
+			continue
 		}
-		if fileNewer {
-			newestIndex = index
-			newestModified = newFileModDate
-			newestPath = raidLogsFolder + "\\" + raidDumpFileList[index]
+		if newest == nil || !file.ModTime().Before(newest.ModTime()) {
+			newest = file
 		}
 	}
-	if newestIndex < 0 {
-		return "", "", fmt.Errorf("empty result")
+	if newest == nil { // No raid dumps yet
+		return "", "", nil
 	}
 
-	return newestPath, newestModified, nil
+	return filepath.Join(raidLogsFolder, newest.Name()), newest.ModTime().String(), nil
 }
 
 func getRaidDumpFiles(basePath string) ([]string, error) {
@@ -748,75 +1539,15 @@ func getRaidDumpFiles(basePath string) ([]string, error) {
 	return raidDumpFileList, nil
 }
 
-func getFileModDate(raidFilePath string) (string, error) {
-	// Get the directory of the current executable
-	EQpath, err := os.Getwd()
-	if err != nil {
-		return "", fmt.Errorf("getFileModDate(): os.getwd: %w", err)
-	}
-	// Get the directory of the RaidLogs folder
-	raidLogsFolder := EQpath + "\\RaidLogs"
-
-	fileStat, err := os.Stat(raidLogsFolder + "\\" + raidFilePath)
-	if err != nil {
-		return "", fmt.Errorf("getFileModDate(%s): os.Stat: %w", raidFilePath, err)
-	}
-	fileModified := fileStat.ModTime().String()
-	return fileModified, nil
-}
-
 func SetRaidFrequency(value int) {
 	raidFrequencyChan <- value
 }
 
 // Organize raid dump files into a RaidLogs subfolder
 func OrganizeRaidDumps() error {
-	//fmt.Println("Starting file organization...")
-	// Get the directory of the current executable
-	EQpath, err := os.Getwd()
+	err := config.OrganizeRaidDumps()
 	if err != nil {
-		return fmt.Errorf("organizeRaidDumps(): os.getwd: %w", err)
-	}
-
-	// Ensure that the RaidLog folder exists
-	raidLogsFolder := EQpath + "\\RaidLogs"
-	raidLogsFolderExists, err := loadFile.FileExists(raidLogsFolder)
-	if err != nil {
-		return fmt.Errorf("organizeRaidDumps(): loadFile.FileExists: %w", err)
-	}
-	if !raidLogsFolderExists {
-		os.Mkdir(raidLogsFolder, 0777)
-		fmt.Printf("Raid Log folder does not exist. Creating: %s\n", raidLogsFolder)
-	}
-
-	// Ensure that the SavedRaids folder exists
-	savedRaidsFolder := EQpath + "\\SavedRaids"
-	savedRaidsFolderExists, err := loadFile.FileExists(savedRaidsFolder)
-	if err != nil {
-		return fmt.Errorf("organizeRaidDumps(): loadFile.FileExists: %w", err)
-	}
-	if !savedRaidsFolderExists {
-		os.Mkdir(savedRaidsFolder, 0777)
-		fmt.Printf("SavedRaids folder does not exist. Creating: %s\n", savedRaidsFolder)
-	}
-
-	// Get the list of raid dump files
-	raidDumpFileList, err := getRaidDumpFiles(EQpath)
-	if err != nil {
-		return fmt.Errorf("organizeRaidDumps(): getRaidDumpFiles: %w", err)
-	}
-	//fmt.Printf("%d logs found that need to be moved...\n", len(raidDumpFileList))
-	// Loop through the raid dump files
-	for _, raidFilePath := range raidDumpFileList {
-		// Get the file name from the path
-		raidFileName := filepath.Base(raidFilePath)
-		// Move the file to the RaidLogs folder
-		newFilePath := raidLogsFolder + "\\" + raidFileName
-		err := loadFile.MoveFile(raidFilePath, newFilePath)
-		if err != nil {
-			return fmt.Errorf("organizeRaidDumps(): copyFile: %w", err)
-		}
-		fmt.Printf("Moving file: %s ---> %s\n", raidFilePath, newFilePath)
+		return fmt.Errorf("OrganizeRaidDumps(): %w", err)
 	}
 	return nil
 }
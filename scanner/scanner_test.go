@@ -1,15 +1,404 @@
 package scanner
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/Valorith/EQRaidAssist/config"
+	"github.com/Valorith/EQRaidAssist/eqlog"
+	"github.com/Valorith/EQRaidAssist/events"
+	"github.com/Valorith/EQRaidAssist/player"
+	"github.com/Valorith/EQRaidAssist/raid"
 )
 
 func TestScanRaid(t *testing.T) {
 
-	isStarted = true
+	raid.Current.SetScannerStarted(true)
 	err := scanRaid()
 	if err != nil {
 		t.Fatalf("scanRaid: %s", err)
 	}
 
 }
+
+func TestIsDuplicateLoot(t *testing.T) {
+	lootSightings = nil
+	parse := func(line string) eqlog.Event {
+		event, err := eqlog.Parse(line)
+		if err != nil {
+			t.Fatalf("eqlog.Parse: %s", err)
+		}
+		return event
+	}
+	award := parse("[Wed Feb 16 20:11:04 2022] Cloak of Flames has been awarded to Valgor by the Loot Council.")
+	if isDuplicateLoot("Main", "Valgor", award) {
+		t.Errorf("first sighting reported as a duplicate")
+	}
+	if !isDuplicateLoot("Banker", "Valgor", award) {
+		t.Errorf("sighting from a second log not reported as a duplicate")
+	}
+	if isDuplicateLoot("Main", "Valgor", award) {
+		t.Errorf("second drop in the same log reported as a duplicate")
+	}
+	later := parse("[Wed Feb 16 20:11:10 2022] Cloak of Flames has been awarded to Valgor by the Loot Council.")
+	if isDuplicateLoot("Banker", "Valgor", later) {
+		t.Errorf("sighting outside the window reported as a duplicate")
+	}
+}
+
+func TestParseRaidDump(t *testing.T) {
+	lines := []string{
+		"\ufeff1\tValgor\t60\tWarrior\tGroup Leader\t\t\r",
+		"1\tShort\t60",
+		"x\tBadgroup\t60\tCleric",
+		"2\tHealer\t60\tCleric\t\t\t\r",
+		"2\tHealer\t60\tCleric",
+		"",
+		"  ",
+	}
+	players, report := parseRaidDump("RaidRoster.txt", lines)
+	if len(players) != 2 || players[0].Name != "Valgor" || players[1].Name != "Healer" {
+		t.Fatalf("parseRaidDump: parsed %+v", players)
+	}
+	if report.Parsed != 2 {
+		t.Errorf("parseRaidDump: report.Parsed = %d, expected 2", report.Parsed)
+	}
+	skippedLines := []int{}
+	for _, skipped := range report.Skipped {
+		skippedLines = append(skippedLines, skipped.Line)
+	}
+	if len(skippedLines) != 3 || skippedLines[0] != 2 || skippedLines[1] != 3 || skippedLines[2] != 5 {
+		t.Errorf("parseRaidDump: skipped lines %v, expected [2 3 5]", skippedLines)
+	}
+}
+
+func TestHandleMasterLooterLoot(t *testing.T) {
+	line := "[Wed Feb 16 20:11:04 2022] Cloak of Flames has been looted by the Master Looter."
+	for _, masterLooter := range []bool{true, false} {
+		lootSightings = nil
+		raid.Current.Reset()
+		raid.Current.AddPlayer(&player.Player{Name: "Looter", MasterLooter: masterLooter})
+		raid.Current.AddPlayer(&player.Player{Name: "Main"})
+		raid.Current.AddPlayer(&player.Player{Name: "Banker"})
+		err := raid.Current.Start("")
+		if err != nil {
+			t.Fatalf("Start: %s", err)
+		}
+		raid.Current.UpdateLeaders()
+
+		// Both watched logs report the same line
+		handleLogLine("Main", true, line, "")
+		handleLogLine("Banker", false, line, "")
+
+		activeRaid := raid.Current.Raid()
+		credited := map[string]int{}
+		for _, p := range activeRaid.Players {
+			credited[p.Name] = len(p.Loot)
+		}
+		if masterLooter && (credited["Looter"] != 1 || credited["Main"] != 0 || credited["Banker"] != 0 || len(activeRaid.Unassigned) != 0) {
+			t.Errorf("with a master looter: credited %v, %d unassigned, expected only Looter", credited, len(activeRaid.Unassigned))
+		}
+		if !masterLooter && (credited["Looter"]+credited["Main"]+credited["Banker"] != 0 || len(activeRaid.Unassigned) != 1) {
+			t.Errorf("without a master looter: credited %v, %d unassigned, expected 1 unassigned", credited, len(activeRaid.Unassigned))
+		}
+	}
+	raid.Current.Reset()
+}
+
+func TestReplay(t *testing.T) {
+	dir := t.TempDir()
+	config.EQDir = dir
+	defer func() { config.EQDir = "" }()
+	files := map[string]string{
+		"Logs/eqlog_Valgor_server.txt": "[Wed Feb 16 19:55:00 2022] You have entered Plane of Fear.\n" +
+			"[Wed Feb 16 20:10:00 2022] Cloak of Flames has been awarded to Healer by the Loot Council.\n" +
+			"[Wed Feb 16 20:12:00 2022] You have entered Plane of Hate.\n",
+		"RaidLogs/RaidRoster_server-20220216-200500.txt": "1\tValgor\t60\tWarrior\tGroup Leader\t\t\n2\tHealer\t60\tCleric\t\t\t\n",
+		"RaidLogs/RaidRoster_server-20220216-201500.txt": "1\tValgor\t60\tWarrior\tGroup Leader\t\t\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("os.MkdirAll: %s", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("ioutil.WriteFile: %s", err)
+		}
+	}
+	raid.Current.Reset()
+	raid.Current.SetScannerStarted(false)
+	currentZone = "Live Zone"
+	defer func() { currentZone = "" }()
+	events.ResetData()
+	defer events.ResetData()
+	var replayed raid.Raid
+	events.Subscribe("test", 0, func(event events.Event) {
+		replayed = event.Snapshot.(raid.Raid)
+	}, events.RaidStopped)
+
+	from := time.Date(2022, time.February, 16, 19, 50, 0, 0, time.Local)
+	err := Replay("eqlog_Valgor_server.txt", from, from.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Replay: %s", err)
+	}
+	if replayed.StartHour != 20 || replayed.StartMinute != 5 {
+		t.Errorf("raid started at %02d:%02d, expected the simulated 20:05", replayed.StartHour, replayed.StartMinute)
+	}
+	zones := []string{}
+	for _, change := range replayed.Zones {
+		zones = append(zones, change.Zone)
+	}
+	if len(zones) != 2 || zones[0] != "Plane of Fear" || zones[1] != "Plane of Hate" {
+		t.Errorf("zones = %v, expected [Plane of Fear Plane of Hate]", zones)
+	}
+	if healer := replayed.GetPlayerByName("Healer"); healer == nil || len(healer.Loot) != 1 {
+		t.Errorf("Healer's loot was not recorded")
+	}
+	if replayed.Checkins["Valgor"] <= replayed.Checkins["Healer"] {
+		t.Errorf("check-ins = %v, expected Valgor to have more than Healer", replayed.Checkins)
+	}
+	if currentZone != "Live Zone" {
+		t.Errorf("replay changed the live scanner's zone to %q", currentZone)
+	}
+}
+
+func TestGetStartOffset(t *testing.T) {
+	logFilePath := filepath.Join(t.TempDir(), "eqlog_Valgor_server.txt")
+	head := "[Wed Feb 16 19:55:00 2022] Welcome to EverQuest!"
+	content := head + "\n[Wed Feb 16 19:56:00 2022] You have entered Plane of Fear.\n"
+	if err := ioutil.WriteFile(logFilePath, []byte(content), 0644); err != nil {
+		t.Fatalf("ioutil.WriteFile: %s", err)
+	}
+	size := int64(len(content))
+	defer func() { config.LogOffsets = nil }()
+	tests := []struct {
+		name     string
+		saved    *config.LogOffset
+		resume   bool
+		expected int64
+	}{
+		{"resumed", &config.LogOffset{Offset: 10, Head: head}, true, 10},
+		{"truncated", &config.LogOffset{Offset: size + 1, Head: head}, true, 0},
+		{"rotated", &config.LogOffset{Offset: 10, Head: "[Thu Feb 17 19:00:00 2022] Welcome to EverQuest!"}, true, 0},
+		{"never saved", nil, true, size},
+		{"not resumed", &config.LogOffset{Offset: 10, Head: head}, false, size},
+	}
+	for _, test := range tests {
+		config.LogOffsets = map[string]config.LogOffset{}
+		if test.saved != nil {
+			config.LogOffsets[filepath.Base(logFilePath)] = *test.saved
+		}
+		offset, err := getStartOffset(logFilePath, test.resume, false)
+		if err != nil {
+			t.Fatalf("%s: getStartOffset: %s", test.name, err)
+		}
+		if offset != test.expected {
+			t.Errorf("%s: offset = %d, expected %d", test.name, offset, test.expected)
+		}
+	}
+}
+
+func TestStopWhileLoopBusy(t *testing.T) {
+	// The loop is busy (ie: waiting on mu in scanRaid) and not receiving from the stop channel
+	mu.Lock()
+	stopSignalChan = make(chan bool)
+	logStopChan = make(chan bool)
+	stopChan := stopSignalChan
+	mu.Unlock()
+	raid.Current.Reset()
+	raid.Current.SetScannerStarted(true)
+
+	stopped := make(chan bool)
+	go func() {
+		Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatalf("Stop blocked while the loop was busy")
+	}
+	select {
+	case <-stopChan:
+	default:
+		t.Errorf("Stop did not signal the loop")
+	}
+	if raid.Current.ScannerStarted() {
+		t.Errorf("scanner still flagged as started")
+	}
+}
+
+func TestHandleLootWhilePaused(t *testing.T) {
+	lootSightings = nil
+	raid.Current.Reset()
+	defer raid.Current.Reset()
+	raid.Current.AddPlayer(&player.Player{Name: "Healer"})
+	err := raid.Current.Start("")
+	if err != nil {
+		t.Fatalf("Start: %s", err)
+	}
+	err = raid.Current.Pause()
+	if err != nil {
+		t.Fatalf("Pause: %s", err)
+	}
+	events.ResetData()
+	defer events.ResetData()
+	published := 0
+	events.Subscribe("test", 0, func(event events.Event) {
+		published++
+	}, events.LootAwarded)
+
+	handleLogLine("Valgor", true, "[Wed Feb 16 20:11:04 2022] Cloak of Flames has been awarded to Healer by the Loot Council.", "")
+	events.Wait()
+	if healer := raid.Current.Raid().GetPlayerByName("Healer"); healer == nil || len(healer.Loot) != 0 {
+		t.Errorf("loot was recorded while the raid was paused")
+	}
+	if published != 0 {
+		t.Errorf("%d loot events published while the raid was paused", published)
+	}
+}
+
+func TestHandleLootReadAgain(t *testing.T) {
+	lootSightings = nil
+	raid.Current.Reset()
+	defer raid.Current.Reset()
+	raid.Current.AddPlayer(&player.Player{Name: "Healer"})
+	err := raid.Current.Start("")
+	if err != nil {
+		t.Fatalf("Start: %s", err)
+	}
+	killTime := time.Date(2022, time.February, 16, 20, 10, 0, 0, time.Local)
+	if !raid.Current.RecordEncounter("Lord Nagafen", "Valgor", killTime, time.Minute) {
+		t.Fatalf("RecordEncounter: the encounter was not recorded")
+	}
+
+	// The same line is read again, ie: after the scanner restarts from a saved offset
+	line := "[Wed Feb 16 20:11:04 2022] Cloak of Flames has been awarded to Healer by the Loot Council."
+	handleLogLine("Valgor", true, line, "")
+	handleLogLine("Valgor", true, line, "")
+
+	activeRaid := raid.Current.Raid()
+	if healer := activeRaid.GetPlayerByName("Healer"); healer == nil || len(healer.Loot) != 1 || healer.Loot[0].Encounter != "Lord Nagafen" {
+		t.Errorf("Healer's loot = %+v, expected one item linked to Lord Nagafen", healer)
+	}
+	if len(activeRaid.Encounters) != 1 || len(activeRaid.Encounters[0].Loot) != 1 {
+		t.Errorf("encounters = %+v, expected one encounter with one item", activeRaid.Encounters)
+	}
+}
+
+func TestLogReplaced(t *testing.T) {
+	dir := t.TempDir()
+	logFilePath := filepath.Join(dir, "eqlog_Valgor_server.txt")
+	write := func(content string) {
+		if err := ioutil.WriteFile(logFilePath, []byte(content), 0644); err != nil {
+			t.Fatalf("ioutil.WriteFile: %s", err)
+		}
+	}
+	first := "[Wed Feb 16 19:55:00 2022] Welcome to EverQuest!\n"
+	write(first)
+	w := &watchedLog{path: logFilePath, offset: int64(len(first))}
+	w.info, w.head = logIdentity(logFilePath)
+	if logReplaced(w) {
+		t.Errorf("unchanged log reported as replaced")
+	}
+
+	write(first + "[Wed Feb 16 19:56:00 2022] You have entered Plane of Fear.\n")
+	if logReplaced(w) {
+		t.Errorf("grown log reported as replaced")
+	}
+
+	// The log is archived and a new one has already grown past the processed offset
+	if err := os.Rename(logFilePath, filepath.Join(dir, "eqlog_Valgor_server_archived.txt")); err != nil {
+		t.Fatalf("os.Rename: %s", err)
+	}
+	write("[Thu Feb 17 19:00:00 2022] Welcome to EverQuest!\n[Thu Feb 17 19:01:00 2022] You have entered Plane of Hate.\n")
+	if !logReplaced(w) {
+		t.Errorf("rotated log that grew past the offset not reported as replaced")
+	}
+
+	w.info, w.head = logIdentity(logFilePath)
+	write("")
+	if !logReplaced(w) {
+		t.Errorf("truncated log not reported as replaced")
+	}
+}
+
+func TestReboot(t *testing.T) {
+	// Starting the scanner reads config.json from the working directory, which is also the default EQ directory
+	dir := t.TempDir()
+	workingDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("os.Getwd: %s", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("os.Chdir: %s", err)
+	}
+	defer os.Chdir(workingDir)
+	defer config.ResetData()
+	logFilePath := filepath.Join(dir, "Logs", "eqlog_Valgor_server.txt")
+	if err := os.MkdirAll(filepath.Dir(logFilePath), 0755); err != nil {
+		t.Fatalf("os.MkdirAll: %s", err)
+	}
+	if err := ioutil.WriteFile(logFilePath, []byte("[Wed Feb 16 19:55:00 2022] You have entered Plane of Fear.\n"), 0644); err != nil {
+		t.Fatalf("ioutil.WriteFile: %s", err)
+	}
+	ResetData()
+	defer ResetData()
+	raid.Current.Reset()
+	defer raid.Current.Reset()
+	SetCharacterName("Valgor")
+	SetServerName("server")
+
+	// Wait for the log scan to start, it reads the resume flag set by Reboot
+	waitForLogs := func() {
+		for start := time.Now(); time.Since(start) < time.Second; time.Sleep(10 * time.Millisecond) {
+			mu.RLock()
+			started := len(watchedLogs) > 0
+			mu.RUnlock()
+			if started {
+				return
+			}
+		}
+		t.Fatalf("the log scan did not start")
+	}
+	Start()
+	waitForLogs()
+	mu.Lock()
+	watchedLogs = nil
+	mu.Unlock()
+
+	// A log scan still starting up reads the resume flag while the scanner reboots
+	rebooted := make(chan bool)
+	scanning := make(chan bool)
+	go func() {
+		defer close(scanning)
+		for {
+			select {
+			case <-rebooted:
+				return
+			default:
+			}
+			mu.RLock()
+			_ = resumeLog
+			mu.RUnlock()
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	Reboot()
+	close(rebooted)
+	<-scanning
+	waitForLogs()
+	if !IsRunning() {
+		t.Errorf("the scanner is not running after a reboot")
+	}
+	mu.RLock()
+	saved := RebootSavedFile
+	mu.RUnlock()
+	if saved != logFilePath {
+		t.Errorf("RebootSavedFile = %q, expected %q", saved, logFilePath)
+	}
+	Stop()
+}